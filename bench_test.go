@@ -19,6 +19,18 @@ func BenchmarkList(b *testing.B) {
 			ls.RPush(genKey(i))
 		}
 	})
+	b.Run("rpush/capped", func(b *testing.B) {
+		ls := NewWithOptions(Options{CapEntries: N})
+		for i := 0; i < b.N; i++ {
+			ls.RPush(genKey(i))
+		}
+	})
+	b.Run("lpush/capped", func(b *testing.B) {
+		ls := NewWithOptions(Options{CapEntries: N})
+		for i := 0; i < b.N; i++ {
+			ls.LPush(genKey(i))
+		}
+	})
 	b.Run("lpop", func(b *testing.B) {
 		ls := genList(0, b.N)
 		b.ResetTimer()
@@ -33,6 +45,35 @@ func BenchmarkList(b *testing.B) {
 			ls.RPop()
 		}
 	})
+	b.Run("move/rotate", func(b *testing.B) {
+		ls := genList(0, N)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			Move(ls, ls, Right, Left)
+		}
+	})
+	b.Run("move/rotate/append", func(b *testing.B) {
+		ls := genList(0, N)
+		buf := make([]byte, 0, 64)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			buf, _ = MoveAppend(ls, ls, Right, Left, buf[:0])
+		}
+	})
+	b.Run("lpopN", func(b *testing.B) {
+		ls := genList(0, b.N*100)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			ls.LPopN(100)
+		}
+	})
+	b.Run("rpopN", func(b *testing.B) {
+		ls := genList(0, b.N*100)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			ls.RPopN(100)
+		}
+	})
 	b.Run("index", func(b *testing.B) {
 		ls := genList(0, N)
 		b.ResetTimer()
@@ -40,6 +81,16 @@ func BenchmarkList(b *testing.B) {
 			ls.Index(i % N)
 		}
 	})
+	b.Run("index/fill-128", func(b *testing.B) {
+		ls := NewWithOptions(Options{Fill: 128})
+		for i := 0; i < N*100; i++ {
+			ls.RPush(genKey(i))
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			ls.Index(i % (N * 100))
+		}
+	})
 	b.Run("set", func(b *testing.B) {
 		ls := genList(0, N)
 		b.ResetTimer()
@@ -74,6 +125,42 @@ func BenchmarkList(b *testing.B) {
 	})
 }
 
+func BenchmarkQueue(b *testing.B) {
+	// mixed producers and consumers
+	b.Run("locked", func(b *testing.B) {
+		ls := NewConcurrent()
+		b.SetParallelism(4)
+		b.RunParallel(func(pb *testing.PB) {
+			for i := 0; pb.Next(); i++ {
+				if i%2 == 0 {
+					ls.RPush(genKey(i))
+				} else {
+					ls.LPop()
+				}
+			}
+		})
+	})
+	for _, ordered := range []bool{false, true} {
+		name := "sharded"
+		if ordered {
+			name += "/ordered"
+		}
+		b.Run(name, func(b *testing.B) {
+			q := NewShardedQueue(ShardedOptions{Ordered: ordered})
+			b.SetParallelism(4)
+			b.RunParallel(func(pb *testing.PB) {
+				for i := 0; pb.Next(); i++ {
+					if i%2 == 0 {
+						q.Push(genKey(i))
+					} else {
+						q.TryPop()
+					}
+				}
+			})
+		})
+	}
+}
+
 func BenchmarkListPack(b *testing.B) {
 	const N = 1000
 	b.Run("set/same-len", func(b *testing.B) {
@@ -0,0 +1,107 @@
+package quicklist
+
+import (
+	"context"
+	"slices"
+	"sync/atomic"
+)
+
+// waiter is a client blocked by BLPop or BRPop on one or more lists.
+type waiter struct {
+	end     End
+	claimed atomic.Bool
+	ch      chan popResult
+}
+
+type popResult struct {
+	list *ConcurrentQuickList
+	val  string
+}
+
+// claim marks the waiter as served, only the first claim succeeds.
+func (w *waiter) claim() bool {
+	return w.claimed.CompareAndSwap(false, true)
+}
+
+// serve hands entries to blocked waiters in FIFO order like Redis,
+// it must be called with the write lock held after entries are added.
+func (c *ConcurrentQuickList) serve() {
+	for len(c.waiters) > 0 && c.ls.Size() > 0 {
+		w := c.waiters[0]
+		c.waiters[0] = nil
+		c.waiters = c.waiters[1:]
+		// the waiter is served by another list or canceled.
+		if !w.claim() {
+			continue
+		}
+		val, _ := c.pop(w.end)
+		w.ch <- popResult{list: c, val: val}
+	}
+}
+
+func (c *ConcurrentQuickList) pop(end End) (string, bool) {
+	if end == Left {
+		return c.ls.LPop()
+	}
+	return c.ls.RPop()
+}
+
+// removeWaiter deletes waiter w from the queue of list.
+func (c *ConcurrentQuickList) removeWaiter(w *waiter) {
+	c.mu.Lock()
+	c.waiters = slices.DeleteFunc(c.waiters, func(x *waiter) bool { return x == w })
+	c.mu.Unlock()
+}
+
+// BLPop pops the head entry of the first non-empty list like Redis BLPOP,
+// it blocks until any of the lists gets an entry or ctx is done.
+// It returns the position of the list in lists and the entry.
+// Blocked clients are served in FIFO order when entries are pushed.
+func BLPop(ctx context.Context, lists ...*ConcurrentQuickList) (int, string, error) {
+	return blockingPop(ctx, lists, Left)
+}
+
+// BRPop is the same as BLPop but pops the tail entry like Redis BRPOP.
+func BRPop(ctx context.Context, lists ...*ConcurrentQuickList) (int, string, error) {
+	return blockingPop(ctx, lists, Right)
+}
+
+func blockingPop(ctx context.Context, lists []*ConcurrentQuickList, end End) (int, string, error) {
+	w := &waiter{end: end, ch: make(chan popResult, 1)}
+
+	var registered []*ConcurrentQuickList
+	defer func() {
+		for _, c := range registered {
+			c.removeWaiter(w)
+		}
+	}()
+
+	for i, c := range lists {
+		c.mu.Lock()
+		if c.ls.Size() > 0 {
+			// may be claimed by a list that it is already waiting on.
+			if w.claim() {
+				val, _ := c.pop(end)
+				c.mu.Unlock()
+				return i, val, nil
+			}
+			c.mu.Unlock()
+			break
+		}
+		c.waiters = append(c.waiters, w)
+		registered = append(registered, c)
+		c.mu.Unlock()
+	}
+
+	var res popResult
+	select {
+	case res = <-w.ch:
+	case <-ctx.Done():
+		if w.claim() {
+			return -1, "", ctx.Err()
+		}
+		// served just before cancellation.
+		res = <-w.ch
+	}
+	return slices.Index(lists, res.list), res.val, nil
+}
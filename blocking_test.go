@@ -0,0 +1,135 @@
+package quicklist
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// waitBlocked waits until list c has n blocked waiters.
+func waitBlocked(c *ConcurrentQuickList, n int) {
+	for {
+		c.mu.Lock()
+		size := len(c.waiters)
+		c.mu.Unlock()
+		if size >= n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestBlocking(t *testing.T) {
+	const N = 1000
+	ctx := context.Background()
+
+	t.Run("ready", func(t *testing.T) {
+		a, b := NewConcurrent(), NewConcurrent()
+		b.RPush("1", "2", "3")
+		i, v, err := BLPop(ctx, a, b)
+		isNil(t, err)
+		equal(t, i, 1)
+		equal(t, v, "1")
+
+		i, v, err = BRPop(ctx, a, b)
+		isNil(t, err)
+		equal(t, i, 1)
+		equal(t, v, "3")
+
+		a.RPush("x")
+		i, v, _ = BLPop(ctx, a, b)
+		equal(t, i, 0)
+		equal(t, v, "x")
+	})
+
+	t.Run("block", func(t *testing.T) {
+		a, b := NewConcurrent(), NewConcurrent()
+		done := make(chan struct{})
+		go func() {
+			i, v, err := BRPop(ctx, a, b)
+			isNil(t, err)
+			equal(t, i, 1)
+			equal(t, v, "2")
+			close(done)
+		}()
+		waitBlocked(b, 1)
+		b.RPush("1", "2")
+		<-done
+
+		// the waiter is removed from all lists.
+		equal(t, len(a.waiters), 0)
+		equal(t, len(b.waiters), 0)
+		equal(t, b.Size(), 1)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		a := NewConcurrent()
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		i, v, err := BLPop(ctx, a)
+		equal(t, errors.Is(err, context.DeadlineExceeded), true)
+		equal(t, i, -1)
+		equal(t, v, "")
+		equal(t, len(a.waiters), 0)
+
+		// pushed entries are not consumed by canceled waiters.
+		a.RPush("1")
+		equal(t, a.Size(), 1)
+	})
+
+	t.Run("fifo", func(t *testing.T) {
+		a := NewConcurrent()
+		results := make([]chan string, 10)
+		for i := range results {
+			results[i] = make(chan string, 1)
+			go func() {
+				_, v, err := BLPop(ctx, a)
+				isNil(t, err)
+				results[i] <- v
+			}()
+			waitBlocked(a, i+1)
+		}
+		for i := range results {
+			a.RPush(genKey(i))
+		}
+		for i := range results {
+			equal(t, <-results[i], genKey(i))
+		}
+	})
+
+	t.Run("stress", func(t *testing.T) {
+		lists := []*ConcurrentQuickList{NewConcurrent(), NewConcurrent(), NewConcurrent()}
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		seen := make(map[string]bool)
+
+		for g := 0; g < 8; g++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < N; i++ {
+					_, v, err := BLPop(ctx, lists...)
+					isNil(t, err)
+					mu.Lock()
+					equal(t, seen[v], false)
+					seen[v] = true
+					mu.Unlock()
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				for i := 0; i < N; i++ {
+					lists[i%len(lists)].RPush(genKey(g*N + i))
+				}
+			}()
+		}
+		wg.Wait()
+		equal(t, len(seen), 8*N)
+		for _, c := range lists {
+			equal(t, c.Size(), 0)
+			equal(t, len(c.waiters), 0)
+		}
+	})
+}
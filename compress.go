@@ -0,0 +1,148 @@
+package quicklist
+
+import (
+	"slices"
+)
+
+const (
+	// minCompressBytes is the min bytes of listpack data to be compressed.
+	minCompressBytes = 48
+
+	// minCompressImprove is the min bytes the compression should save.
+	minCompressImprove = 8
+)
+
+// compressed reports whether the listpack data of node is compressed.
+func (n *Node) compressed() bool {
+	return n.lzf != nil
+}
+
+// dataLen returns the length of listpack data before compression.
+func (n *Node) dataLen() int {
+	if n.compressed() {
+		return n.rawLen
+	}
+	return len(n.data)
+}
+
+// compressNode compresses the listpack data of node,
+// returns false if the data is too small or compression does not help.
+func (n *Node) compressNode() bool {
+	if n.compressed() {
+		return true
+	}
+	// the list compresses nodes regardless of pins when it is modified,
+	// since the uses are over by then, e.g. an abandoned iterator.
+	n.recompress = false
+	n.pins = 0
+	spare := n.spare
+	n.spare = nil
+	if len(n.data) < minCompressBytes {
+		return false
+	}
+
+	// the spare buffer fits the data decompressed from it, otherwise the
+	// data is compressed into a pooled buffer and copied to the right size.
+	var buf []byte
+	if spare != nil {
+		buf = lzfCompress(spare, n.data)
+	} else {
+		buf = lzfCompress(bpool.Get(len(n.data))[:0], n.data)
+	}
+	if len(buf)+minCompressImprove >= len(n.data) {
+		if spare == nil {
+			bpool.Put(buf)
+		}
+		return false
+	}
+	if spare == nil {
+		n.lzf = slices.Clone(buf)
+		bpool.Put(buf)
+	} else {
+		n.lzf = buf
+	}
+	n.rawLen = len(n.data)
+	if n.pooled {
+		bpool.Put(n.data)
+		n.pooled = false
+	}
+	n.data = nil
+	return true
+}
+
+// decompressNode restores the listpack data of node.
+func (n *Node) decompressNode() {
+	n.recompress = false
+	n.pins = 0
+	if !n.compressed() {
+		return
+	}
+	data, err := lzfDecompress(bpool.Get(n.rawLen)[:0], n.lzf)
+	if err != nil {
+		panic(err)
+	}
+	n.data = data
+	n.pooled = true
+	n.lzf = nil
+	n.rawLen = 0
+}
+
+// decompressForUse decompresses node for temporary use and pins it,
+// it should be followed by recompressOnly when the use is done.
+// Uses can be nested, e.g. reading the list inside a Range callback.
+// Nodes that are not compressed are left untouched, so reads do not write them.
+func (n *Node) decompressForUse() {
+	if n.compressed() {
+		spare := n.lzf[:0]
+		n.decompressNode()
+		n.spare = spare
+		n.recompress = true
+	}
+	if n.recompress {
+		n.pins++
+	}
+}
+
+// recompressOnly unpins node and compresses it again if it was decompressed
+// for use and it is the last use.
+func (n *Node) recompressOnly() {
+	if !n.recompress {
+		return
+	}
+	if n.pins--; n.pins <= 0 {
+		n.compressNode()
+	}
+}
+
+// compress makes sure that nodes within CompressDepth from both ends
+// are not compressed, and compresses node n if it is out of the depth.
+// Same as __quicklistCompress in Redis.
+func (ls *QuickList) compress(n *Node) {
+	depth := ls.opts.CompressDepth
+	if depth <= 0 {
+		return
+	}
+
+	forward, reverse := ls.head, ls.tail
+	var inDepth bool
+	for i := 0; i < depth; i++ {
+		forward.decompressNode()
+		reverse.decompressNode()
+		if forward == n || reverse == n {
+			inDepth = true
+		}
+		// the whole list is within depth, nothing to compress.
+		if forward == reverse || forward.next == reverse {
+			return
+		}
+		forward = forward.next
+		reverse = reverse.prev
+	}
+
+	if n != nil && !inDepth {
+		n.compressNode()
+	}
+	// forward and reverse are one node beyond depth now.
+	forward.compressNode()
+	reverse.compressNode()
+}
@@ -0,0 +1,320 @@
+package quicklist
+
+import (
+	"iter"
+	"sync"
+	"unsafe"
+)
+
+// ConcurrentQuickList is a goroutine-safe quicklist protected by a RWMutex.
+// Readers like Index and Range run in parallel and writers are exclusive.
+// When compression is enabled, reading decompresses nodes temporarily,
+// so readers take the write lock as well.
+//
+// Use Do for atomic multi-step operations and the Iterator.
+type ConcurrentQuickList struct {
+	mu       sync.RWMutex
+	ls       *QuickList
+	compress bool
+
+	// waiters are clients blocked by BLPop or BRPop in FIFO order.
+	waiters []*waiter
+}
+
+// NewConcurrent create a goroutine-safe quicklist instance with DefaultOptions.
+func NewConcurrent() *ConcurrentQuickList {
+	return NewConcurrentWithOptions(DefaultOptions)
+}
+
+// NewConcurrentWithOptions create a goroutine-safe quicklist instance with given options.
+func NewConcurrentWithOptions(opts Options) *ConcurrentQuickList {
+	ls := NewWithOptions(opts)
+	return &ConcurrentQuickList{ls: ls, compress: ls.opts.CompressDepth > 0}
+}
+
+func (c *ConcurrentQuickList) rlock() {
+	if c.compress {
+		c.mu.Lock()
+	} else {
+		c.mu.RLock()
+	}
+}
+
+func (c *ConcurrentQuickList) runlock() {
+	if c.compress {
+		c.mu.Unlock()
+	} else {
+		c.mu.RUnlock()
+	}
+}
+
+// Do calls f with the underlying quicklist under the write lock,
+// f must not retain the quicklist after return.
+func (c *ConcurrentQuickList) Do(f func(ls *QuickList)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	f(c.ls)
+	c.serve()
+}
+
+// LPush
+func (c *ConcurrentQuickList) LPush(keys ...string) {
+	c.mu.Lock()
+	c.ls.LPush(keys...)
+	c.serve()
+	c.mu.Unlock()
+}
+
+// RPush
+func (c *ConcurrentQuickList) RPush(keys ...string) {
+	c.mu.Lock()
+	c.ls.RPush(keys...)
+	c.serve()
+	c.mu.Unlock()
+}
+
+// LPushBytes
+func (c *ConcurrentQuickList) LPushBytes(keys ...[]byte) {
+	c.mu.Lock()
+	c.ls.LPushBytes(keys...)
+	c.serve()
+	c.mu.Unlock()
+}
+
+// RPushBytes
+func (c *ConcurrentQuickList) RPushBytes(keys ...[]byte) {
+	c.mu.Lock()
+	c.ls.RPushBytes(keys...)
+	c.serve()
+	c.mu.Unlock()
+}
+
+// Insert
+func (c *ConcurrentQuickList) Insert(index int, values ...string) {
+	c.mu.Lock()
+	c.ls.Insert(index, values...)
+	c.serve()
+	c.mu.Unlock()
+}
+
+// InsertBefore
+func (c *ConcurrentQuickList) InsertBefore(pivot, value string) (int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n, ok := c.ls.InsertBefore(pivot, value)
+	c.serve()
+	return n, ok
+}
+
+// InsertAfter
+func (c *ConcurrentQuickList) InsertAfter(pivot, value string) (int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n, ok := c.ls.InsertAfter(pivot, value)
+	c.serve()
+	return n, ok
+}
+
+// Move is the same as Move but locks both lists, c and dst can be the same list.
+func (c *ConcurrentQuickList) Move(dst *ConcurrentQuickList, from, to End) (string, bool) {
+	if c == dst {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return Move(c.ls, c.ls, from, to)
+	}
+	// lock in address order to avoid deadlock.
+	first, second := c, dst
+	if uintptr(unsafe.Pointer(first)) > uintptr(unsafe.Pointer(second)) {
+		first, second = second, first
+	}
+	first.mu.Lock()
+	defer first.mu.Unlock()
+	second.mu.Lock()
+	defer second.mu.Unlock()
+	val, ok := Move(c.ls, dst.ls, from, to)
+	dst.serve()
+	return val, ok
+}
+
+// Index
+func (c *ConcurrentQuickList) Index(i int) (string, bool) {
+	c.rlock()
+	defer c.runlock()
+	return c.ls.Index(i)
+}
+
+// IndexBytes
+func (c *ConcurrentQuickList) IndexBytes(i int, dst []byte) ([]byte, bool) {
+	c.rlock()
+	defer c.runlock()
+	return c.ls.IndexBytes(i, dst)
+}
+
+// LPop
+func (c *ConcurrentQuickList) LPop() (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ls.LPop()
+}
+
+// RPop
+func (c *ConcurrentQuickList) RPop() (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ls.RPop()
+}
+
+// LPopAppend
+func (c *ConcurrentQuickList) LPopAppend(dst []byte) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ls.LPopAppend(dst)
+}
+
+// RPopAppend
+func (c *ConcurrentQuickList) RPopAppend(dst []byte) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ls.RPopAppend(dst)
+}
+
+// LPopN
+func (c *ConcurrentQuickList) LPopN(n int) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ls.LPopN(n)
+}
+
+// RPopN
+func (c *ConcurrentQuickList) RPopN(n int) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ls.RPopN(n)
+}
+
+// AppendLPopN
+func (c *ConcurrentQuickList) AppendLPopN(dst []string, n int) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ls.AppendLPopN(dst, n)
+}
+
+// AppendRPopN
+func (c *ConcurrentQuickList) AppendRPopN(dst []string, n int) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ls.AppendRPopN(dst, n)
+}
+
+// Set
+func (c *ConcurrentQuickList) Set(index int, key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ls.Set(index, key)
+}
+
+// Remove
+func (c *ConcurrentQuickList) Remove(index int) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ls.Remove(index)
+}
+
+// RemoveFirst
+func (c *ConcurrentQuickList) RemoveFirst(key string) (int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ls.RemoveFirst(key)
+}
+
+// RemoveN
+func (c *ConcurrentQuickList) RemoveN(value string, count int) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ls.RemoveN(value, count)
+}
+
+// Pos
+func (c *ConcurrentQuickList) Pos(value string, opts PosOptions) []int {
+	c.rlock()
+	defer c.runlock()
+	return c.ls.Pos(value, opts)
+}
+
+// Trim
+func (c *ConcurrentQuickList) Trim(start, stop int) {
+	c.mu.Lock()
+	c.ls.Trim(start, stop)
+	c.mu.Unlock()
+}
+
+// Size
+func (c *ConcurrentQuickList) Size() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.ls.Size()
+}
+
+// Range holds the lock while calling f, so f must not call any method of c, or it
+// deadlocks: the lock is exclusive when compression is on, and a nested read lock
+// waits behind a pending writer otherwise. Use Do to read and modify the list together.
+func (c *ConcurrentQuickList) Range(start, end int, f lsIterator) {
+	c.rlock()
+	defer c.runlock()
+	c.ls.Range(start, end, f)
+}
+
+// RevRange is the same as Range but iterates from the tail, f must not call methods of c.
+func (c *ConcurrentQuickList) RevRange(start, end int, f lsIterator) {
+	c.rlock()
+	defer c.runlock()
+	c.ls.RevRange(start, end, f)
+}
+
+// All holds the lock during the iteration like Range, the loop body must not call methods of c.
+func (c *ConcurrentQuickList) All() iter.Seq2[int, []byte] {
+	return c.seq2(c.ls.All())
+}
+
+// Backward holds the lock during the iteration like Range, the loop body must not call methods of c.
+func (c *ConcurrentQuickList) Backward() iter.Seq2[int, []byte] {
+	return c.seq2(c.ls.Backward())
+}
+
+// Slice holds the lock during the iteration like Range, the loop body must not call methods of c.
+func (c *ConcurrentQuickList) Slice(start, end int) iter.Seq2[int, []byte] {
+	return c.seq2(c.ls.Slice(start, end))
+}
+
+// Values holds the lock during the iteration like Range, the loop body must not call methods of c.
+func (c *ConcurrentQuickList) Values() iter.Seq[string] {
+	return func(yield func(string) bool) {
+		c.rlock()
+		defer c.runlock()
+		c.ls.Values()(yield)
+	}
+}
+
+func (c *ConcurrentQuickList) seq2(seq iter.Seq2[int, []byte]) iter.Seq2[int, []byte] {
+	return func(yield func(int, []byte) bool) {
+		c.rlock()
+		defer c.runlock()
+		seq(yield)
+	}
+}
+
+// MarshalBinary
+func (c *ConcurrentQuickList) MarshalBinary() ([]byte, error) {
+	c.rlock()
+	defer c.runlock()
+	return c.ls.MarshalBinary()
+}
+
+// UnmarshalBinary
+func (c *ConcurrentQuickList) UnmarshalBinary(src []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	err := c.ls.UnmarshalBinary(src)
+	c.serve()
+	return err
+}
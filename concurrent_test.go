@@ -0,0 +1,140 @@
+package quicklist
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestConcurrent(t *testing.T) {
+	const N = 1000
+	const G = 8
+
+	for _, depth := range []int{0, 1} {
+		opts := Options{MaxListPackEntries: 16, CompressDepth: depth}
+
+		t.Run("push-pop/depth-"+strconv.Itoa(depth), func(t *testing.T) {
+			ls := NewConcurrentWithOptions(opts)
+			var popped atomic.Int64
+			var wg sync.WaitGroup
+			for g := 0; g < G; g++ {
+				wg.Add(3)
+				go func() {
+					defer wg.Done()
+					for i := 0; i < N; i++ {
+						ls.RPush(genKey(i))
+						ls.LPushBytes([]byte(genKey(i)))
+					}
+				}()
+				go func() {
+					defer wg.Done()
+					for i := 0; i < N; i++ {
+						ls.Index(i)
+						ls.Range(0, 10, func([]byte) bool { return false })
+						for range ls.Slice(-5, -1) {
+						}
+						ls.Size()
+					}
+				}()
+				go func() {
+					defer wg.Done()
+					for i := 0; i < N/2; i++ {
+						if _, ok := ls.LPop(); ok {
+							popped.Add(1)
+						}
+						if _, ok := ls.RPopAppend(nil); ok {
+							popped.Add(1)
+						}
+					}
+				}()
+			}
+			wg.Wait()
+			equal(t, ls.Size(), G*N*2-int(popped.Load()))
+			ls.Do(func(ls *QuickList) {
+				checkIndex(t, ls)
+				checkCompress(t, ls)
+			})
+		})
+	}
+
+	t.Run("do", func(t *testing.T) {
+		ls := NewConcurrent()
+		ls.RPush("0")
+		var wg sync.WaitGroup
+		for g := 0; g < G; g++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < N; i++ {
+					// read-modify-write is atomic
+					ls.Do(func(ls *QuickList) {
+						v, _ := ls.Index(0)
+						n, _ := strconv.Atoi(v)
+						ls.Set(0, strconv.Itoa(n+1))
+					})
+				}
+			}()
+		}
+		wg.Wait()
+		v, _ := ls.Index(0)
+		equal(t, v, strconv.Itoa(G*N))
+	})
+
+	t.Run("move", func(t *testing.T) {
+		a, b := NewConcurrent(), NewConcurrent()
+		for i := 0; i < N; i++ {
+			a.RPush(genKey(i))
+			b.RPush(genKey(i))
+		}
+		var wg sync.WaitGroup
+		for g := 0; g < G; g++ {
+			wg.Add(3)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < N; i++ {
+					a.Move(b, Left, Right)
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				for i := 0; i < N; i++ {
+					b.Move(a, Right, Left)
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				for i := 0; i < N; i++ {
+					a.Move(a, Left, Right)
+				}
+			}()
+		}
+		wg.Wait()
+		equal(t, a.Size()+b.Size(), N*2)
+	})
+
+	t.Run("marshal", func(t *testing.T) {
+		ls := NewConcurrentWithOptions(Options{MaxListPackEntries: 16, CompressDepth: 1})
+		for i := 0; i < N; i++ {
+			ls.RPush(genKey(i))
+		}
+		var wg sync.WaitGroup
+		for g := 0; g < G; g++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				data, err := ls.MarshalBinary()
+				isNil(t, err)
+				ls2 := NewConcurrent()
+				isNil(t, ls2.UnmarshalBinary(data))
+				equal(t, ls2.Size(), N)
+				var count int
+				for v := range ls2.Values() {
+					equal(t, v, genKey(count))
+					count++
+				}
+			}()
+		}
+		wg.Wait()
+	})
+}
@@ -0,0 +1,197 @@
+package quicklist
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// DefaultResolution is the default bucket width of DelayedQueue.
+const DefaultResolution = time.Second
+
+// DelayedOptions is the configuration of a delayed queue.
+type DelayedOptions struct {
+	// Options of the underlying quicklists.
+	Options
+
+	// Resolution is the time span of each bucket, items are delivered
+	// no earlier than due and at most one resolution late.
+	// Default is DefaultResolution.
+	Resolution time.Duration
+
+	// Now returns the current time, default is time.Now.
+	Now func() time.Time
+}
+
+// DelayedQueue is a goroutine-safe queue that delivers items after their due time.
+// Pending items are kept in quicklist buckets by due time rounded up to Resolution,
+// and due buckets are promoted to the ready list by Poll or Run.
+/*
+	buckets:
+	+------+------+-----+------+
+	| t0   | t1   | ... | tN   |  sorted keys
+	+------+------+-----+------+
+	   |      |            |
+	 list   list         list   payloads in scheduling order
+*/
+type DelayedQueue struct {
+	mu         sync.Mutex
+	opts       DelayedOptions
+	bucketOpts Options
+	keys       []int64
+	buckets    map[int64]*QuickList
+	pending    int
+	ready      *QuickList
+
+	// notEmpty is closed to wake up the blocked Pop.
+	notEmpty chan struct{}
+}
+
+// NewDelayedQueue create a delayed queue instance with given options.
+func NewDelayedQueue(opts DelayedOptions) *DelayedQueue {
+	if opts.Resolution <= 0 {
+		opts.Resolution = DefaultResolution
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &DelayedQueue{
+		opts:       opts,
+		bucketOpts: opts.uncapped(),
+		buckets:    make(map[int64]*QuickList),
+		ready:      NewWithOptions(opts.Options),
+	}
+}
+
+// bucketKey returns the key of bucket that t falls in, which is t rounded up to resolution.
+func (q *DelayedQueue) bucketKey(t time.Time) int64 {
+	res := int64(q.opts.Resolution)
+	ns := t.UnixNano()
+	key := ns / res * res
+	if key < ns {
+		key += res
+	}
+	return key
+}
+
+// Schedule adds value to be delivered at due, it is ready immediately if due is passed.
+func (q *DelayedQueue) Schedule(value string, due time.Time) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if !due.After(q.opts.Now()) {
+		q.ready.RPush(value)
+		wake(&q.notEmpty)
+		return
+	}
+	key := q.bucketKey(due)
+	bucket, ok := q.buckets[key]
+	if !ok {
+		bucket = NewWithOptions(q.bucketOpts)
+		q.buckets[key] = bucket
+		i, _ := slices.BinarySearch(q.keys, key)
+		q.keys = slices.Insert(q.keys, i, key)
+	}
+	bucket.RPush(value)
+	q.pending++
+}
+
+// Delay adds value to be delivered after d.
+func (q *DelayedQueue) Delay(value string, d time.Duration) {
+	q.Schedule(value, q.opts.Now().Add(d))
+}
+
+// Poll promotes the items of buckets due at now to the ready list in the
+// order of buckets, returns the number of promoted items.
+func (q *DelayedQueue) Poll(now time.Time) (n int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	ns := now.UnixNano()
+	var k int
+	for ; k < len(q.keys) && q.keys[k] <= ns; k++ {
+		bucket := q.buckets[q.keys[k]]
+		for _, data := range bucket.All() {
+			q.ready.RPushBytes(data)
+		}
+		n += bucket.Size()
+		delete(q.buckets, q.keys[k])
+	}
+	q.keys = slices.Delete(q.keys, 0, k)
+	q.pending -= n
+	if n > 0 {
+		wake(&q.notEmpty)
+	}
+	return
+}
+
+// Run polls the queue every interval until ctx is done.
+func (q *DelayedQueue) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			q.Poll(q.opts.Now())
+		}
+	}
+}
+
+// Cancel removes the pending items equal to value, items already promoted
+// to the ready list are not affected. Returns the number of removed items.
+func (q *DelayedQueue) Cancel(value string) (n int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.keys = slices.DeleteFunc(q.keys, func(key int64) bool {
+		bucket := q.buckets[key]
+		n += bucket.RemoveN(value, 0)
+		if bucket.Size() == 0 {
+			delete(q.buckets, key)
+			return true
+		}
+		return false
+	})
+	q.pending -= n
+	return
+}
+
+// TryPop removes the head item of ready list without blocking.
+func (q *DelayedQueue) TryPop() (string, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.ready.LPop()
+}
+
+// Pop removes the head item of ready list, it blocks until an item is ready or ctx is done.
+// Items are promoted by Poll or Run, so Pop waits forever if neither is called.
+func (q *DelayedQueue) Pop(ctx context.Context) (string, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for {
+		if val, ok := q.ready.LPop(); ok {
+			return val, nil
+		}
+		if err := waitFor(ctx, &q.mu, &q.notEmpty); err != nil {
+			return "", err
+		}
+	}
+}
+
+// Len returns the number of ready items.
+func (q *DelayedQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.ready.Size()
+}
+
+// Pending returns the number of items that are not due yet.
+func (q *DelayedQueue) Pending() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.pending
+}
@@ -0,0 +1,109 @@
+package quicklist
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDelayedQueue(t *testing.T) {
+	const N = 1000
+	ctx := context.Background()
+
+	t.Run("poll", func(t *testing.T) {
+		clock := &fakeClock{now: time.Unix(1000, 0)}
+		q := NewDelayedQueue(DelayedOptions{Resolution: time.Second, Now: clock.Now})
+
+		// schedule in reverse order of due time
+		for i := N - 1; i >= 0; i-- {
+			q.Delay(genKey(i), time.Duration(i+1)*time.Second)
+		}
+		equal(t, q.Pending(), N)
+		equal(t, q.Len(), 0)
+		equal(t, q.Poll(clock.Now()), 0)
+
+		// never early
+		equal(t, q.Poll(clock.Now().Add(time.Second-1)), 0)
+		for i := 0; i < N; i++ {
+			clock.Add(time.Second)
+			equal(t, q.Poll(clock.Now()), 1)
+			v, ok := q.TryPop()
+			equal(t, ok, true)
+			equal(t, v, genKey(i))
+		}
+		equal(t, q.Pending(), 0)
+		equal(t, len(q.buckets), 0)
+		equal(t, len(q.keys), 0)
+
+		// passed due is ready immediately
+		q.Schedule("now", clock.Now())
+		q.Delay("past", -time.Second)
+		equal(t, q.Len(), 2)
+		equal(t, q.Pending(), 0)
+	})
+
+	t.Run("bucket", func(t *testing.T) {
+		clock := &fakeClock{now: time.Unix(1000, 0)}
+		q := NewDelayedQueue(DelayedOptions{Resolution: time.Minute, Now: clock.Now})
+
+		// at most one resolution late, in the order of scheduling within a bucket
+		q.Delay("b", 10*time.Second)
+		q.Delay("a", 5*time.Second)
+		q.Delay("c", 90*time.Second)
+		equal(t, len(q.keys), 2)
+
+		equal(t, q.Poll(clock.Now().Add(10*time.Second)), 0)
+		equal(t, q.Poll(clock.Now().Add(time.Minute)), 2)
+		equal(t, q.Pending(), 1)
+		v, _ := q.TryPop()
+		equal(t, v, "b")
+		v, _ = q.TryPop()
+		equal(t, v, "a")
+
+		equal(t, q.Poll(clock.Now().Add(time.Hour)), 1)
+		v, _ = q.TryPop()
+		equal(t, v, "c")
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		clock := &fakeClock{now: time.Unix(1000, 0)}
+		q := NewDelayedQueue(DelayedOptions{Now: clock.Now})
+		for i := 0; i < N; i++ {
+			q.Delay(genKey(i%10), time.Duration(i%100+1)*time.Second)
+		}
+		equal(t, q.Cancel(genKey(3)), N/10)
+		equal(t, q.Cancel(genKey(3)), 0)
+		equal(t, q.Pending(), N-N/10)
+
+		// buckets that only hold canceled items are dropped
+		for i := 0; i < 10; i++ {
+			if i != 3 {
+				q.Cancel(genKey(i))
+			}
+		}
+		equal(t, q.Pending(), 0)
+		equal(t, len(q.buckets), 0)
+		equal(t, len(q.keys), 0)
+		equal(t, q.Poll(clock.Now().Add(time.Hour)), 0)
+	})
+
+	t.Run("run", func(t *testing.T) {
+		q := NewDelayedQueue(DelayedOptions{Resolution: time.Millisecond})
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go q.Run(ctx, time.Millisecond)
+
+		start := time.Now()
+		q.Delay("x", 20*time.Millisecond)
+		v, err := q.Pop(ctx)
+		isNil(t, err)
+		equal(t, v, "x")
+		equal(t, time.Since(start) >= 20*time.Millisecond, true)
+
+		tctx, tcancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer tcancel()
+		_, err = q.Pop(tctx)
+		equal(t, errors.Is(err, context.DeadlineExceeded), true)
+	})
+}
@@ -1,3 +1,3 @@
 module github.com/xgzlucario/quicklist
 
-go 1.22
+go 1.23
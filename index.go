@@ -0,0 +1,227 @@
+package quicklist
+
+import "math/rand/v2"
+
+// nodeIndex is a treap over the nodes in list order, each tree node keeps
+// the number of entries in its subtree. It locates the node of a global index,
+// and inserts or removes a node anywhere in the list in O(log n) expected.
+/*
+	           node2(sum=12)
+	          /             \
+	   node0(sum=5)     node3(sum=4)
+	          \
+	       node1(sum=2)
+
+	The in-order traversal is the order of list, nodes are balanced by
+	random priorities which are heap-ordered from the root.
+*/
+type nodeIndex struct {
+	root  *Node
+	total int
+}
+
+// treeLinks is the position of a node in nodeIndex.
+type treeLinks struct {
+	left, right, parent *Node
+	// prio is 0 when the node is not in index.
+	prio  uint32
+	count int
+	sum   int
+}
+
+// indexed reports whether node n is in the index.
+func (n *Node) indexed() bool {
+	return n.tree.prio != 0
+}
+
+func subtreeSum(n *Node) int {
+	if n == nil {
+		return 0
+	}
+	return n.tree.sum
+}
+
+func newPrio() uint32 {
+	return rand.Uint32() | 1
+}
+
+// rebuild rebuilds the index with nodes linked from head in O(n).
+func (x *nodeIndex) rebuild(head *Node) {
+	// build the cartesian tree of priorities, stack is the right spine.
+	var stack []*Node
+	for n := head; n != nil; n = n.next {
+		n.tree = treeLinks{prio: newPrio(), count: n.Size()}
+		var last *Node
+		for len(stack) > 0 && stack[len(stack)-1].tree.prio < n.tree.prio {
+			last = stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+		}
+		n.tree.left = last
+		if last != nil {
+			last.tree.parent = n
+		}
+		if len(stack) > 0 {
+			top := stack[len(stack)-1]
+			top.tree.right = n
+			n.tree.parent = top
+		}
+		stack = append(stack, n)
+	}
+	x.root = nil
+	if len(stack) > 0 {
+		x.root = stack[0]
+	}
+	x.total = x.sum(x.root)
+}
+
+// sum computes the subtree sums of n.
+func (x *nodeIndex) sum(n *Node) int {
+	if n == nil {
+		return 0
+	}
+	n.tree.sum = n.tree.count + x.sum(n.tree.left) + x.sum(n.tree.right)
+	return n.tree.sum
+}
+
+// add adds delta to the size of node n.
+func (x *nodeIndex) add(n *Node, delta int) {
+	n.tree.count += delta
+	for ; n != nil; n = n.tree.parent {
+		n.tree.sum += delta
+	}
+	x.total += delta
+}
+
+// replace links child in place of old under the parent of old.
+func (x *nodeIndex) replace(old, child *Node) {
+	parent := old.tree.parent
+	switch {
+	case parent == nil:
+		x.root = child
+	case parent.tree.left == old:
+		parent.tree.left = child
+	default:
+		parent.tree.right = child
+	}
+	if child != nil {
+		child.tree.parent = parent
+	}
+}
+
+// rotateUp rotates node n above its parent.
+func (x *nodeIndex) rotateUp(n *Node) {
+	p := n.tree.parent
+	x.replace(p, n)
+	if p.tree.left == n {
+		p.tree.left = n.tree.right
+		if n.tree.right != nil {
+			n.tree.right.tree.parent = p
+		}
+		n.tree.right = p
+	} else {
+		p.tree.right = n.tree.left
+		if n.tree.left != nil {
+			n.tree.left.tree.parent = p
+		}
+		n.tree.left = p
+	}
+	p.tree.parent = n
+
+	n.tree.sum = p.tree.sum
+	p.tree.sum = p.tree.count + subtreeSum(p.tree.left) + subtreeSum(p.tree.right)
+}
+
+// insert adds node n which is already linked in the list.
+func (x *nodeIndex) insert(n *Node) {
+	n.tree = treeLinks{prio: newPrio(), count: n.Size(), sum: n.Size()}
+
+	// n is the successor of prev, or the predecessor of next in the tree.
+	switch {
+	case n.prev != nil:
+		p := n.prev
+		if p.tree.right == nil {
+			p.tree.right = n
+		} else {
+			for p = p.tree.right; p.tree.left != nil; p = p.tree.left {
+			}
+			p.tree.left = n
+		}
+		n.tree.parent = p
+
+	case n.next != nil:
+		p := n.next
+		if p.tree.left == nil {
+			p.tree.left = n
+		} else {
+			for p = p.tree.left; p.tree.right != nil; p = p.tree.right {
+			}
+			p.tree.right = n
+		}
+		n.tree.parent = p
+
+	default:
+		x.root = n
+	}
+	for p := n.tree.parent; p != nil; p = p.tree.parent {
+		p.tree.sum += n.tree.count
+	}
+	x.total += n.tree.count
+
+	for n.tree.parent != nil && n.tree.parent.tree.prio < n.tree.prio {
+		x.rotateUp(n)
+	}
+}
+
+// remove deletes node n which is already unlinked from the list.
+func (x *nodeIndex) remove(n *Node) {
+	// rotate n down to a leaf.
+	for {
+		l, r := n.tree.left, n.tree.right
+		if l == nil && r == nil {
+			break
+		}
+		if r == nil || (l != nil && l.tree.prio > r.tree.prio) {
+			x.rotateUp(l)
+		} else {
+			x.rotateUp(r)
+		}
+	}
+	x.replace(n, nil)
+	for p := n.tree.parent; p != nil; p = p.tree.parent {
+		p.tree.sum -= n.tree.count
+	}
+	x.total -= n.tree.count
+	n.tree = treeLinks{}
+}
+
+// find returns the node and the index inside it based on global index.
+func (x *nodeIndex) find(index int) (*Node, int) {
+	if index < 0 || index >= x.total {
+		return nil, 0
+	}
+	n := x.root
+	for {
+		left := subtreeSum(n.tree.left)
+		if index < left {
+			n = n.tree.left
+			continue
+		}
+		index -= left
+		if index < n.tree.count {
+			return n, index
+		}
+		index -= n.tree.count
+		n = n.tree.right
+	}
+}
+
+// offset returns the global index of the first entry of node n.
+func (x *nodeIndex) offset(n *Node) int {
+	sum := subtreeSum(n.tree.left)
+	for ; n.tree.parent != nil; n = n.tree.parent {
+		if p := n.tree.parent; p.tree.right == n {
+			sum += subtreeSum(p.tree.left) + p.tree.count
+		}
+	}
+	return sum
+}
@@ -0,0 +1,96 @@
+package quicklist
+
+import (
+	"math/bits"
+	"math/rand/v2"
+	"testing"
+)
+
+// checkIndex checks the node index and bytes of list with a linear scan.
+func checkIndex(t *testing.T, ls *QuickList) {
+	var size, bytes int
+	for n := ls.head; n != nil; n = n.next {
+		bytes += n.dataLen()
+		equal(t, n.indexed(), true)
+		equal(t, n.tree.count, n.Size())
+		equal(t, n.tree.sum, n.Size()+subtreeSum(n.tree.left)+subtreeSum(n.tree.right))
+		if p := n.tree.parent; p != nil {
+			equal(t, n.tree.prio <= p.tree.prio, true)
+			equal(t, p.tree.left == n || p.tree.right == n, true)
+		} else {
+			equal(t, ls.index.root, n)
+		}
+		equal(t, ls.index.offset(n), size)
+		for i := 0; i < n.Size(); i++ {
+			node, indexInternal := ls.index.find(size + i)
+			equal(t, node, n)
+			equal(t, indexInternal, i)
+		}
+		size += n.Size()
+	}
+	equal(t, ls.Size(), size)
+	equal(t, ls.Bytes(), bytes)
+
+	node, _ := ls.index.find(size)
+	equal(t, node, (*Node)(nil))
+	node, _ = ls.index.find(-1)
+	equal(t, node, (*Node)(nil))
+}
+
+func TestIndex(t *testing.T) {
+	const N = 1000
+
+	t.Run("push", func(t *testing.T) {
+		ls := NewWithOptions(Options{MaxListPackEntries: 3})
+		checkIndex(t, ls)
+		for i := 0; i < N; i++ {
+			if i%2 == 0 {
+				ls.LPush(genKey(i))
+			} else {
+				ls.RPush(genKey(i))
+			}
+		}
+		checkIndex(t, ls)
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		ls := NewWithOptions(Options{MaxListPackEntries: 3})
+		for i := 0; i < N; i++ {
+			ls.RPush(genKey(i))
+		}
+		for ls.Size() > 0 {
+			_, ok := ls.Remove(rand.IntN(ls.Size()))
+			equal(t, true, ok)
+			if ls.Size()%50 == 0 {
+				checkIndex(t, ls)
+			}
+		}
+		checkIndex(t, ls)
+	})
+
+	t.Run("split", func(t *testing.T) {
+		ls := NewWithOptions(Options{MaxListPackEntries: 8})
+		for i := 0; i < N*10; i++ {
+			ls.RPush(genKey(i))
+		}
+		// split nodes in the middle repeatedly.
+		for i := 0; i < N*5; i++ {
+			ls.Insert(ls.Size()/2, genKey(i))
+		}
+		checkIndex(t, ls)
+
+		var nodes int
+		for n := ls.head; n != nil; n = n.next {
+			nodes++
+		}
+		lessOrEqual(t, height(ls.index.root), 4*bits.Len(uint(nodes)))
+	})
+}
+
+// height returns the height of subtree n.
+func height(n *Node) int {
+	if n == nil {
+		return 0
+	}
+	return 1 + max(height(n.tree.left), height(n.tree.right))
+}
@@ -0,0 +1,249 @@
+package quicklist
+
+import (
+	"slices"
+)
+
+// Iterator is a bidirectional iterator of quicklist. It tracks the current node
+// and byte offset inside the listpack, so sequential traversal and edits are O(1)
+// amortized instead of locating the entry from scratch.
+//
+// The cursor of iterator lies between entries like a text cursor, Next returns
+// the entry after the cursor and Prev returns the entry before it:
+//
+//	it := ls.Iterator(0)
+//	for it.Next() {
+//		if string(it.Value()) == "foo" {
+//			it.Remove()
+//		}
+//	}
+//
+// The list must not be modified other than through the iterator while iterating,
+// reading it is allowed. Call Close if the iteration stops before Next or Prev
+// returns false, so the node of cursor can be compressed again.
+type Iterator struct {
+	ls *QuickList
+
+	// cursor
+	node   *Node
+	pinned bool // node is decompressed for use
+	pos    int  // byte offset in node
+	off    int  // entry offset in node
+	index  int  // global index
+
+	// the entry returned by Next or Prev
+	last    entry
+	forward bool
+	val     []byte
+	buf     []byte
+}
+
+type entry struct {
+	index      int // -1 means no entry
+	start, end int
+}
+
+// Iterator returns an iterator whose cursor is before the entry at start,
+// negative start counts from the tail, and out of range start is clamped.
+func (ls *QuickList) Iterator(start int) *Iterator {
+	it := &Iterator{ls: ls}
+	if start < 0 {
+		start += ls.Size()
+	}
+	it.locate(min(max(start, 0), ls.Size()))
+	return it
+}
+
+// Seek moves the cursor before the entry at index i, i == Size() means the end of list.
+// Negative index counts from the tail.
+func (it *Iterator) Seek(i int) bool {
+	size := it.ls.Size()
+	if i < 0 {
+		i += size
+	}
+	if i < 0 || i > size {
+		return false
+	}
+	it.leave()
+	it.locate(i)
+	return true
+}
+
+// locate moves the cursor before the entry at index i without leaving the current node,
+// it is used after the nodes are changed.
+func (it *Iterator) locate(i int) {
+	n, off := it.ls.find(i)
+	if n == nil {
+		n, off = it.ls.tail, it.ls.tail.Size()
+	}
+	it.node = n
+	it.enter()
+	it.off = off
+	it.pos = n.seek(off)
+	it.index = i
+	it.last = entry{index: -1}
+	it.val = nil
+}
+
+// enter decompresses the node of cursor for use.
+func (it *Iterator) enter() {
+	if !it.pinned {
+		it.node.decompressForUse()
+		it.pinned = true
+	}
+}
+
+// leave recompresses the node of cursor when iterator leaves it.
+func (it *Iterator) leave() {
+	if it.pinned {
+		it.node.recompressOnly()
+		it.pinned = false
+	}
+}
+
+// Close releases the node of cursor, the iterator can still be used after it.
+func (it *Iterator) Close() {
+	it.leave()
+}
+
+// Next moves the cursor forward and returns true if there is an entry.
+func (it *Iterator) Next() bool {
+	if it.index >= it.ls.Size() {
+		it.leave()
+		return false
+	}
+	it.enter()
+	for it.pos >= len(it.node.data) {
+		it.leave()
+		it.node = it.node.next
+		it.enter()
+		it.pos, it.off = 0, 0
+	}
+	data, size := readEntry(it.node.data[it.pos:], &it.buf)
+	it.val = data
+	it.last = entry{index: it.index, start: it.pos, end: it.pos + size}
+	it.forward = true
+
+	it.pos += size
+	it.off++
+	it.index++
+	return true
+}
+
+// Prev moves the cursor backward and returns true if there is an entry.
+func (it *Iterator) Prev() bool {
+	if it.index <= 0 {
+		it.leave()
+		return false
+	}
+	it.enter()
+	for it.pos == 0 {
+		it.leave()
+		it.node = it.node.prev
+		it.enter()
+		it.pos, it.off = len(it.node.data), it.node.Size()
+	}
+	entryLen, n := uvarintReverse(it.node.data[:it.pos])
+	start := it.pos - int(entryLen) - n
+	it.val, _ = readEntry(it.node.data[start:it.pos], &it.buf)
+	it.last = entry{index: it.index - 1, start: start, end: it.pos}
+	it.forward = false
+
+	it.pos = start
+	it.off--
+	it.index--
+	return true
+}
+
+// Value returns the entry returned by Next or Prev, it is valid until
+// the iterator moves or modifies the list.
+func (it *Iterator) Value() []byte {
+	return it.val
+}
+
+// Index returns the index of the entry returned by Next or Prev,
+// -1 means there is no entry.
+func (it *Iterator) Index() int {
+	return it.last.index
+}
+
+// lastOff returns the entry offset in node of the entry returned by Next or Prev.
+func (it *Iterator) lastOff() int {
+	if it.forward {
+		return it.off - 1
+	}
+	return it.off
+}
+
+// Remove deletes the entry returned by Next or Prev.
+func (it *Iterator) Remove() bool {
+	if it.last.index < 0 {
+		return false
+	}
+	it.enter()
+	n := it.node
+	n.data = slices.Delete(n.data, it.last.start, it.last.end)
+	n.size--
+	it.ls.bytes -= it.last.end - it.last.start
+	it.ls.index.add(n, -1)
+
+	// the cursor is after the removed entry.
+	if it.forward {
+		it.pos = it.last.start
+		it.off--
+		it.index--
+	}
+	it.last = entry{index: -1}
+	it.val = nil
+
+	// nodes may be released or merged, locate the cursor again.
+	if n.size == 0 || it.ls.underfilled(n) {
+		it.leave()
+		it.ls.free(n)
+		it.locate(it.index)
+	}
+	return true
+}
+
+// Set updates the entry returned by Next or Prev.
+func (it *Iterator) Set(value string) bool {
+	if it.last.index < 0 {
+		return false
+	}
+	it.enter()
+	n := it.node
+	alloc := appendEntry(nil, value)
+	if len(alloc) == it.last.end-it.last.start {
+		copy(n.data[it.last.start:], alloc)
+	} else {
+		n.data = slices.Replace(n.data, it.last.start, it.last.end, alloc...)
+	}
+	delta := len(alloc) - (it.last.end - it.last.start)
+	it.ls.bytes += delta
+	bpool.Put(alloc)
+
+	if it.forward {
+		it.pos += delta
+	}
+	it.last.end += delta
+	it.val, _ = readEntry(n.data[it.last.start:it.last.end], &it.buf)
+	return true
+}
+
+// InsertAfter inserts value after the entry returned by Next or Prev,
+// the inserted entry is skipped when iterating forward.
+func (it *Iterator) InsertAfter(value string) bool {
+	if it.last.index < 0 {
+		return false
+	}
+	cursor := it.index
+	if it.forward {
+		cursor++
+	}
+	it.leave()
+	it.ls.insertAt(it.node, it.lastOff()+1, value)
+	// entries before the cursor may be evicted by the caps.
+	cursor = max(cursor-it.ls.evict(Left), 0)
+	it.locate(cursor)
+	return true
+}
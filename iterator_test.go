@@ -0,0 +1,200 @@
+package quicklist
+
+import (
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+// checkValues checks all entries of list with vls.
+func checkValues(t *testing.T, ls *QuickList, vls []string) {
+	var values []string
+	ls.Range(0, -1, func(data []byte) bool {
+		values = append(values, string(data))
+		return false
+	})
+	equal(t, slices.Equal(values, vls), true)
+}
+
+func TestIterator(t *testing.T) {
+	const N = 1000
+
+	newList := func() (*QuickList, []string) {
+		ls := NewWithOptions(Options{MaxListPackEntries: 8, CompressDepth: 1})
+		var vls []string
+		for i := 0; i < N; i++ {
+			ls.RPush(genKey(i))
+			vls = append(vls, genKey(i))
+		}
+		return ls, vls
+	}
+
+	t.Run("next", func(t *testing.T) {
+		ls, vls := newList()
+		it := ls.Iterator(0)
+		for i := 0; it.Next(); i++ {
+			equal(t, it.Index(), i)
+			equal(t, string(it.Value()), vls[i])
+		}
+		equal(t, it.Index(), N-1)
+		equal(t, false, it.Next())
+
+		it = ls.Iterator(-10)
+		var count int
+		for it.Next() {
+			count++
+		}
+		equal(t, count, 10)
+
+		// empty list
+		it = New().Iterator(0)
+		equal(t, false, it.Next())
+		equal(t, false, it.Prev())
+		equal(t, it.Index(), -1)
+	})
+
+	t.Run("prev", func(t *testing.T) {
+		ls, vls := newList()
+		it := ls.Iterator(ls.Size())
+		for i := N - 1; it.Prev(); i-- {
+			equal(t, it.Index(), i)
+			equal(t, string(it.Value()), vls[i])
+		}
+		equal(t, it.Index(), 0)
+		equal(t, false, it.Prev())
+	})
+
+	t.Run("seek", func(t *testing.T) {
+		ls, vls := newList()
+		it := ls.Iterator(0)
+		for i := 0; i < N; i++ {
+			k := rand.IntN(N)
+			equal(t, true, it.Seek(k))
+			equal(t, it.Index(), -1)
+			equal(t, true, it.Next())
+			equal(t, string(it.Value()), vls[k])
+			equal(t, true, it.Prev())
+			equal(t, string(it.Value()), vls[k])
+		}
+		equal(t, true, it.Seek(-1))
+		equal(t, true, it.Next())
+		equal(t, string(it.Value()), vls[N-1])
+
+		equal(t, false, it.Seek(N+1))
+		equal(t, false, it.Seek(-N-1))
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		ls, vls := newList()
+		it := ls.Iterator(0)
+		equal(t, false, it.Remove())
+		for it.Next() {
+			if rand.IntN(3) > 0 {
+				vls = slices.Delete(vls, it.Index(), it.Index()+1)
+				equal(t, true, it.Remove())
+				equal(t, false, it.Remove())
+			}
+		}
+		equal(t, ls.Size(), len(vls))
+		checkValues(t, ls, vls)
+
+		// remove backward
+		it = ls.Iterator(ls.Size())
+		for it.Prev() {
+			vls = slices.Delete(vls, it.Index(), it.Index()+1)
+			equal(t, true, it.Remove())
+		}
+		equal(t, ls.Size(), 0)
+		equal(t, ls.head, ls.tail)
+		checkIndex(t, ls)
+	})
+
+	t.Run("set", func(t *testing.T) {
+		ls, vls := newList()
+		it := ls.Iterator(0)
+		equal(t, false, it.Set("x"))
+		for it.Next() {
+			// change the entry size and encoding
+			var v string
+			switch rand.IntN(3) {
+			case 0:
+				v = strconv.Itoa(rand.IntN(100))
+			case 1:
+				v = genKey(it.Index()) + "-abcdefghijk"
+			default:
+				v = "s"
+			}
+			vls[it.Index()] = v
+			equal(t, true, it.Set(v))
+			equal(t, string(it.Value()), v)
+		}
+		for it.Prev() {
+			equal(t, string(it.Value()), vls[it.Index()])
+		}
+		checkValues(t, ls, vls)
+	})
+
+	t.Run("insertAfter", func(t *testing.T) {
+		ls, vls := newList()
+		it := ls.Iterator(0)
+		equal(t, false, it.InsertAfter("x"))
+		for it.Next() {
+			i := it.Index()
+			equal(t, string(it.Value()), vls[i])
+			if rand.IntN(2) == 0 {
+				vls = slices.Insert(vls, i+1, "ins"+strconv.Itoa(i))
+				equal(t, true, it.InsertAfter("ins"+strconv.Itoa(i)))
+			}
+		}
+		checkValues(t, ls, vls)
+
+		// insert backward
+		for it.Prev() {
+			i := it.Index()
+			vls = slices.Insert(vls, i+1, "back")
+			equal(t, true, it.InsertAfter("back"))
+			equal(t, true, it.Seek(i))
+		}
+		checkValues(t, ls, vls)
+		checkIndex(t, ls)
+	})
+
+	t.Run("random", func(t *testing.T) {
+		ls, vls := newList()
+		it := ls.Iterator(N / 2)
+		for i := 0; i < N*10; i++ {
+			switch rand.IntN(6) {
+			case 0, 1:
+				if it.Next() {
+					equal(t, string(it.Value()), vls[it.Index()])
+				}
+			case 2:
+				if it.Prev() {
+					equal(t, string(it.Value()), vls[it.Index()])
+				}
+			case 3:
+				if k := it.Index(); k >= 0 {
+					vls = slices.Delete(vls, k, k+1)
+					equal(t, true, it.Remove())
+				}
+			case 4:
+				if k := it.Index(); k >= 0 {
+					v := strconv.Itoa(i)
+					vls[k] = v
+					equal(t, true, it.Set(v))
+				}
+			case 5:
+				if k := it.Index(); k >= 0 {
+					v := genKey(i)
+					vls = slices.Insert(vls, k+1, v)
+					equal(t, true, it.InsertAfter(v))
+				}
+			}
+		}
+		checkValues(t, ls, vls)
+		checkIndex(t, ls)
+		it.Seek(0)
+		checkCompress(t, ls)
+	})
+}
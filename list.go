@@ -1,9 +1,11 @@
 package quicklist
 
 import (
+	"bytes"
 	"encoding/binary"
 	"errors"
 	"math"
+	"slices"
 )
 
 //	 +------------------------------ QuickList -----------------------------+
@@ -14,119 +16,599 @@ import (
 // QuickList is double linked listpack.
 type QuickList struct {
 	head, tail *Node
+	opts       Options
+	index      nodeIndex
+
+	// bytes is the total size of listpack data of all nodes before compression.
+	bytes int
 }
 
 type Node struct {
 	*ListPack
 	prev, next *Node
-}
 
-func SetMaxListPackSize(s int) {
-	maxListPackSize = s
+	// tree is the position of node in nodeIndex.
+	tree treeLinks
+
+	// lzf is the compressed listpack data, see CompressDepth.
+	lzf        []byte
+	rawLen     int
+	recompress bool
+	// pins is the number of uses that decompressed node by decompressForUse.
+	pins int
+	// spare is the lzf buffer kept for recompressOnly, pooled reports whether
+	// data is allocated from bpool by decompression.
+	spare  []byte
+	pooled bool
 }
 
-// New create a quicklist instance.
+// New create a quicklist instance with DefaultOptions.
 func New() *QuickList {
+	return NewWithOptions(DefaultOptions)
+}
+
+// NewWithOptions create a quicklist instance with given options.
+func NewWithOptions(opts Options) *QuickList {
 	n := newNode()
-	return &QuickList{head: n, tail: n}
+	ls := &QuickList{head: n, tail: n, opts: opts.normalize()}
+	ls.index.rebuild(n)
+	return ls
 }
 
 func newNode() *Node {
 	return &Node{ListPack: NewListPack()}
 }
 
+// allowInsert reports whether an entry of size bytes can be inserted
+// into node n without exceeding the node limits.
+func (ls *QuickList) allowInsert(n *Node, size int) bool {
+	if n.size == 0 {
+		return true
+	}
+	if ls.opts.MaxListPackEntries > 0 && n.Size() >= ls.opts.MaxListPackEntries {
+		return false
+	}
+	return n.dataLen()+size <= ls.opts.MaxListPackSize
+}
+
+// insertNode links node n before or after the old node.
+func (ls *QuickList) insertNode(old, n *Node, after bool) {
+	if after {
+		n.prev, n.next = old, old.next
+		if old.next != nil {
+			old.next.prev = n
+		} else {
+			ls.tail = n
+		}
+		old.next = n
+	} else {
+		n.prev, n.next = old.prev, old
+		if old.prev != nil {
+			old.prev.next = n
+		} else {
+			ls.head = n
+		}
+		old.prev = n
+	}
+	ls.index.insert(n)
+	ls.compress(old)
+}
+
+// split splits node n at offset, entries after offset are moved into a new node.
+func (ls *QuickList) split(n *Node, offset int) *Node {
+	pos := n.seek(offset)
+	next := newNode()
+	next.data = append(next.data, n.data[pos:]...)
+	next.size = n.size - uint32(offset)
+
+	ls.index.add(n, -next.Size())
+	n.data = n.data[:pos]
+	n.size = uint32(offset)
+
+	ls.insertNode(n, next, true)
+	return next
+}
+
 func (ls *QuickList) lpush(key string) {
-	if len(ls.head.data)+len(key) >= maxListPackSize {
-		n := newNode()
-		n.next = ls.head
-		ls.head.prev = n
-		ls.head = n
+	if !ls.allowInsert(ls.head, maxEntrySize(len(key))) {
+		ls.insertNode(ls.head, newNode(), false)
 	}
+	before := len(ls.head.data)
 	ls.head.Insert(0, key)
+	ls.bytes += len(ls.head.data) - before
+	ls.index.add(ls.head, 1)
 }
 
 // LPush
 func (ls *QuickList) LPush(keys ...string) {
 	for _, k := range keys {
 		ls.lpush(k)
+		ls.evict(Right)
 	}
 }
 
 func (ls *QuickList) rpush(key string) {
-	if len(ls.tail.data)+len(key) >= maxListPackSize {
-		n := newNode()
-		ls.tail.next = n
-		n.prev = ls.tail
-		ls.tail = n
+	if !ls.allowInsert(ls.tail, maxEntrySize(len(key))) {
+		ls.insertNode(ls.tail, newNode(), true)
 	}
+	before := len(ls.tail.data)
 	ls.tail.Insert(-1, key)
+	ls.bytes += len(ls.tail.data) - before
+	ls.index.add(ls.tail, 1)
 }
 
 // RPush
 func (ls *QuickList) RPush(keys ...string) {
 	for _, k := range keys {
 		ls.rpush(k)
+		ls.evict(Left)
+	}
+}
+
+// LPushBytes is the same as LPush but takes []byte, keys are copied into
+// listpack without intermediate strings.
+func (ls *QuickList) LPushBytes(keys ...[]byte) {
+	for _, k := range keys {
+		ls.lpush(b2s(k))
+		ls.evict(Right)
+	}
+}
+
+// RPushBytes is the same as RPush but takes []byte, keys are copied into
+// listpack without intermediate strings.
+func (ls *QuickList) RPushBytes(keys ...[]byte) {
+	for _, k := range keys {
+		ls.rpush(b2s(k))
+		ls.evict(Left)
+	}
+}
+
+// Insert inserts values before the entry at index, negative index counts from
+// the tail like ListPack.Insert, e.g. -1 means the end of list.
+// The listpack node is split into two nodes when it would exceed the limits.
+func (ls *QuickList) Insert(index int, values ...string) {
+	if index < 0 {
+		index += ls.Size() + 1
+	}
+	if index < 0 || index > ls.Size() {
+		return
+	}
+	for i, v := range values {
+		ls.insert(index+i, v)
+	}
+	ls.evict(Left)
+}
+
+func (ls *QuickList) insert(index int, key string) {
+	if index == 0 {
+		ls.lpush(key)
+		return
+	}
+	if index == ls.Size() {
+		ls.rpush(key)
+		return
+	}
+
+	n, indexInternal := ls.find(index)
+	ls.insertAt(n, indexInternal, key)
+}
+
+// insertAt inserts key into node n before the entry at indexInternal,
+// indexInternal == n.Size() means after the last entry of node.
+func (ls *QuickList) insertAt(n *Node, indexInternal int, key string) {
+	n.decompressNode()
+
+	switch {
+	case ls.allowInsert(n, maxEntrySize(len(key))):
+		before := len(n.data)
+		n.Insert(indexInternal, key)
+		ls.bytes += len(n.data) - before
+		ls.index.add(n, 1)
+
+	case indexInternal == n.Size():
+		// insert at the head of next node, or a new node between them.
+		next := n.next
+		if next == nil || !ls.allowInsert(next, maxEntrySize(len(key))) {
+			next = newNode()
+			ls.insertNode(n, next, true)
+		}
+		next.decompressNode()
+		before := len(next.data)
+		next.Insert(0, key)
+		ls.bytes += len(next.data) - before
+		ls.index.add(next, 1)
+		ls.compress(next)
+
+	case indexInternal == 0:
+		// insert at the tail of prev node, or a new node between them.
+		prev := n.prev
+		if prev == nil || !ls.allowInsert(prev, maxEntrySize(len(key))) {
+			prev = newNode()
+			ls.insertNode(n, prev, false)
+		}
+		prev.decompressNode()
+		before := len(prev.data)
+		prev.Insert(-1, key)
+		ls.bytes += len(prev.data) - before
+		ls.index.add(prev, 1)
+		ls.compress(prev)
+
+	default:
+		next := ls.split(n, indexInternal)
+		// n may be compressed again when linking the new node.
+		n.decompressNode()
+		target := n
+		if !ls.allowInsert(n, maxEntrySize(len(key))) {
+			target = newNode()
+			ls.insertNode(n, target, true)
+		}
+		before := len(target.data)
+		target.Insert(-1, key)
+		ls.bytes += len(target.data) - before
+		ls.index.add(target, 1)
+		ls.compress(target)
+		ls.compress(next)
+	}
+	ls.compress(n)
+}
+
+// InsertBefore inserts value before the first entry equal to pivot like
+// Redis LINSERT, returns the new length of list and false if pivot is not found.
+func (ls *QuickList) InsertBefore(pivot, value string) (int, bool) {
+	return ls.insertPivot(pivot, value, false)
+}
+
+// InsertAfter inserts value after the first entry equal to pivot like
+// Redis LINSERT, returns the new length of list and false if pivot is not found.
+func (ls *QuickList) InsertAfter(pivot, value string) (int, bool) {
+	return ls.insertPivot(pivot, value, true)
+}
+
+func (ls *QuickList) insertPivot(pivot, value string, after bool) (int, bool) {
+	for n := ls.head; n != nil; n = n.next {
+		n.decompressForUse()
+		index, _, _ := n.findFirst(pivot)
+		if index < 0 {
+			n.recompressOnly()
+			continue
+		}
+		if after {
+			index++
+		}
+		// keep n decompressed for insertAt.
+		n.decompressNode()
+		ls.insertAt(n, index, value)
+		ls.evict(Left)
+		return ls.Size(), true
 	}
+	return 0, false
+}
+
+// End is the side of list.
+type End int
+
+const (
+	Left End = iota
+	Right
+)
+
+// opposite returns the other side of list.
+func (e End) opposite() End {
+	if e == Left {
+		return Right
+	}
+	return Left
+}
+
+// Move pops an entry from the `from` side of src and pushes it to the `to` side
+// of dst like Redis LMOVE, src and dst can be the same list for rotation.
+// The encoded entry is moved between listpacks as raw bytes without decoding,
+// the only allocation is the returned string, use MoveAppend to avoid it.
+func Move(src, dst *QuickList, from, to End) (string, bool) {
+	buf, ok := MoveAppend(src, dst, from, to, bpool.Get(64)[:0])
+	val := string(buf)
+	bpool.Put(buf)
+	return val, ok
+}
+
+// MoveAppend is the same as Move but appends the moved entry to buf, so
+// the caller can reuse the buffer and rotating a list does not allocate.
+func MoveAppend(src, dst *QuickList, from, to End, buf []byte) ([]byte, bool) {
+	start := len(buf)
+	buf, ok := src.popRaw(buf, from)
+	if !ok {
+		return buf, false
+	}
+	entry := buf[start:]
+	dst.pushRaw(entry, to)
+	dst.evict(to.opposite())
+
+	// replace the encoded entry with its value.
+	return appendValue(buf[:start], entry), true
 }
 
-// Index
+// popRaw removes the entry at end of list and appends its encoded bytes to dst.
+func (ls *QuickList) popRaw(dst []byte, end End) ([]byte, bool) {
+	index := 0
+	if end == Right {
+		index = ls.Size() - 1
+	}
+	n, _ := ls.find(index)
+	if n == nil {
+		return dst, false
+	}
+	n.decompressForUse()
+	before := len(dst)
+	dst = n.popRaw(dst, end == Left)
+	ls.bytes -= len(dst) - before
+	n.recompressOnly()
+	ls.index.add(n, -1)
+	ls.free(n)
+	return dst, true
+}
+
+// pushRaw inserts the encoded entry at end of list.
+func (ls *QuickList) pushRaw(entry []byte, end End) {
+	ls.bytes += len(entry)
+	if end == Left {
+		if !ls.allowInsert(ls.head, len(entry)) {
+			ls.insertNode(ls.head, newNode(), false)
+		}
+		ls.head.pushRaw(entry, true)
+		ls.index.add(ls.head, 1)
+		return
+	}
+	if !ls.allowInsert(ls.tail, len(entry)) {
+		ls.insertNode(ls.tail, newNode(), true)
+	}
+	ls.tail.pushRaw(entry, false)
+	ls.index.add(ls.tail, 1)
+}
+
+// Index returns the entry at index i, negative index counts from the tail,
+// e.g. -1 means the last entry.
 func (ls *QuickList) Index(i int) (val string, ok bool) {
-	ls.Range(i, i+1, func(key []byte) bool {
+	if i < 0 {
+		i += ls.Size()
+	}
+	ls.iterFront(i, i+1, func(key []byte) bool {
 		val, ok = string(key), true
 		return true
 	})
 	return
 }
 
+// IndexBytes appends the entry at index i to dst and returns the extended buffer,
+// dst is returned unchanged if i is out of range. Negative index counts from the tail.
+func (ls *QuickList) IndexBytes(i int, dst []byte) ([]byte, bool) {
+	if i < 0 {
+		i += ls.Size()
+	}
+	var ok bool
+	ls.iterFront(i, i+1, func(key []byte) bool {
+		dst, ok = append(dst, key...), true
+		return true
+	})
+	return dst, ok
+}
+
 // LPop
 func (ls *QuickList) LPop() (string, bool) {
 	return ls.Remove(0)
 }
 
 // RPop
-func (ls *QuickList) RPop() (key string, ok bool) {
-	for lp := ls.tail; lp != nil; lp = lp.prev {
-		if lp.size > 0 {
-			return lp.Remove(-1)
-		}
-		ls.free(lp)
+func (ls *QuickList) RPop() (string, bool) {
+	return ls.Remove(-1)
+}
+
+// LPopAppend pops the head entry and appends it to dst,
+// so the caller can reuse the buffer instead of allocating a string.
+func (ls *QuickList) LPopAppend(dst []byte) ([]byte, bool) {
+	return ls.removeAppend(dst, 0)
+}
+
+// RPopAppend pops the tail entry and appends it to dst.
+func (ls *QuickList) RPopAppend(dst []byte) ([]byte, bool) {
+	return ls.removeAppend(dst, -1)
+}
+
+// removeAppend deletes the entry at index and appends it to dst.
+func (ls *QuickList) removeAppend(dst []byte, index int) ([]byte, bool) {
+	if index < 0 {
+		index += ls.Size()
 	}
-	return
+	lp, indexInternal := ls.find(index)
+	if lp == nil {
+		return dst, false
+	}
+	lp.decompressForUse()
+	before := len(lp.data)
+	dst = lp.removeAppend(dst, indexInternal)
+	ls.bytes -= before - len(lp.data)
+	lp.recompressOnly()
+	ls.index.add(lp, -1)
+	ls.free(lp)
+	return dst, true
+}
+
+// LPopN pops at most n entries from the head.
+func (ls *QuickList) LPopN(n int) []string {
+	return ls.AppendLPopN(nil, n)
+}
+
+// RPopN pops at most n entries from the tail.
+func (ls *QuickList) RPopN(n int) []string {
+	return ls.AppendRPopN(nil, n)
+}
+
+// AppendLPopN pops at most n entries from the head and appends them to dst,
+// all strings are built from one backing allocation.
+func (ls *QuickList) AppendLPopN(dst []string, n int) []string {
+	return ls.popN(dst, n, Left)
 }
 
-// free release empty list node.
+// AppendRPopN pops at most n entries from the tail and appends them to dst,
+// all strings are built from one backing allocation.
+func (ls *QuickList) AppendRPopN(dst []string, n int) []string {
+	return ls.popN(dst, n, Right)
+}
+
+func (ls *QuickList) popN(dst []string, n int, end End) []string {
+	n = min(n, ls.Size())
+	if n <= 0 {
+		return dst
+	}
+	iter := ls.iterFront
+	if end == Right {
+		iter = ls.iterBack
+	}
+
+	var total int
+	iter(0, n, func(data []byte) bool {
+		total += len(data)
+		return false
+	})
+	buf := make([]byte, 0, total)
+	dst = slices.Grow(dst, n)
+	iter(0, n, func(data []byte) bool {
+		start := len(buf)
+		buf = append(buf, data...)
+		dst = append(dst, b2s(buf[start:]))
+		return false
+	})
+
+	if n == ls.Size() {
+		ls.clear()
+		return dst
+	}
+	if end == Left {
+		ls.trimFront(n)
+	} else {
+		ls.trimBack(n)
+	}
+	return dst
+}
+
+// free is called after entries are deleted from node n, it releases node n
+// if it is empty, or merges it with a neighbour if it is underfilled and
+// the combined listpack fits the limits, like _quicklistMergeNodes in Redis.
 func (ls *QuickList) free(n *Node) {
-	if n.size == 0 && n.prev != nil && n.next != nil {
-		n.prev.next = n.next
-		n.next.prev = n.prev
-		bpool.Put(n.data)
-		n = nil
+	if n.size == 0 {
+		ls.delNode(n)
+		return
+	}
+	if !ls.underfilled(n) {
+		return
+	}
+	if n.prev != nil && ls.allowMerge(n.prev, n) {
+		ls.mergeNodes(n.prev, n)
+	} else if n.next != nil && ls.allowMerge(n, n.next) {
+		ls.mergeNodes(n, n.next)
 	}
 }
 
-// find quickly locates `listpack` and it `indexInternal` based on index.
-func (ls *QuickList) find(index int) (*Node, int) {
-	var n *Node
-	for n = ls.head; n != nil && index >= n.Size(); n = n.next {
-		index -= n.Size()
+// delNode unlinks node n from list, the last node is always kept.
+func (ls *QuickList) delNode(n *Node) {
+	if n.prev == nil && n.next == nil {
+		return
+	}
+	ls.delNodes(n, n)
+}
+
+// delNodes unlinks the consecutive nodes from first to last in a single step,
+// they must not be all nodes of list.
+func (ls *QuickList) delNodes(first, last *Node) {
+	prev, next := first.prev, last.next
+	if prev != nil {
+		prev.next = next
+	} else {
+		ls.head = next
+	}
+	if next != nil {
+		next.prev = prev
+	} else {
+		ls.tail = prev
+	}
+
+	for n := first; n != next; n = n.next {
+		ls.bytes -= n.dataLen()
+		ls.index.remove(n)
+		if !n.compressed() {
+			bpool.Put(n.data)
+		}
+	}
+	ls.compress(nil)
+}
+
+// underfilled reports whether node n drops below 1/4 of the limits.
+func (ls *QuickList) underfilled(n *Node) bool {
+	if ls.opts.MaxListPackEntries > 0 && n.Size()*4 < ls.opts.MaxListPackEntries {
+		return true
 	}
-	return n, index
+	return n.dataLen()*4 < ls.opts.MaxListPackSize
 }
 
-// Set
+// allowMerge reports whether the combined listpack of two nodes fits the limits.
+func (ls *QuickList) allowMerge(a, b *Node) bool {
+	if ls.opts.MaxListPackEntries > 0 && a.Size()+b.Size() > ls.opts.MaxListPackEntries {
+		return false
+	}
+	return a.dataLen()+b.dataLen() <= ls.opts.MaxListPackSize
+}
+
+// mergeNodes appends entries of next into prev and deletes next.
+func (ls *QuickList) mergeNodes(prev, next *Node) {
+	prev.decompressNode()
+	next.decompressNode()
+
+	prev.data = append(prev.data, next.data...)
+	prev.size += next.size
+	// bytes of next are subtracted by delNode.
+	ls.bytes += len(next.data)
+	ls.index.add(prev, next.Size())
+
+	ls.delNode(next)
+	ls.compress(prev)
+}
+
+// find quickly locates `listpack` and it `indexInternal` based on index in O(log n).
+func (ls *QuickList) find(index int) (*Node, int) {
+	return ls.index.find(index)
+}
+
+// Set updates the entry at index, negative index counts from the tail.
 func (ls *QuickList) Set(index int, key string) bool {
+	if index < 0 {
+		index += ls.Size()
+	}
 	lp, indexInternal := ls.find(index)
 	if lp != nil {
-		return lp.Set(indexInternal, key)
+		lp.decompressForUse()
+		before := len(lp.data)
+		ok := lp.Set(indexInternal, key)
+		ls.bytes += len(lp.data) - before
+		lp.recompressOnly()
+		return ok
 	}
 	return false
 }
 
-// Remove
+// Remove deletes the entry at index, negative index counts from the tail.
 func (ls *QuickList) Remove(index int) (val string, ok bool) {
+	if index < 0 {
+		index += ls.Size()
+	}
 	lp, indexInternal := ls.find(index)
 	if lp != nil {
+		lp.decompressForUse()
+		before := len(lp.data)
 		val, ok = lp.Remove(indexInternal)
+		ls.bytes -= before - len(lp.data)
+		lp.recompressOnly()
+		if ok {
+			ls.index.add(lp, -1)
+		}
 		ls.free(lp)
 	}
 	return
@@ -135,29 +617,300 @@ func (ls *QuickList) Remove(index int) (val string, ok bool) {
 // RemoveFirst
 func (ls *QuickList) RemoveFirst(key string) (res int, ok bool) {
 	for lp := ls.head; lp != nil; lp = lp.next {
-		if lp.size == 0 {
+		lp.decompressForUse()
+		before := len(lp.data)
+		n, ok := lp.RemoveFirst(key)
+		ls.bytes -= before - len(lp.data)
+		lp.recompressOnly()
+		if ok {
+			ls.index.add(lp, -1)
 			ls.free(lp)
+			return res + n, true
+		}
+		res += lp.Size()
+	}
+	return 0, false
+}
 
-		} else {
-			n, ok := lp.RemoveFirst(key)
-			if ok {
-				return res + n, true
-			} else {
-				res += lp.Size()
+// RemoveN removes entries equal to value like Redis LREM, it removes at most
+// count entries from the head when count > 0, from the tail when count < 0,
+// or all of them when count == 0. Returns the number of removed entries.
+func (ls *QuickList) RemoveN(value string, count int) (removed int) {
+	if count < 0 {
+		count = -count
+		for n := ls.tail; n != nil && removed < count; {
+			prev := n.prev
+			n.decompressForUse()
+			before := len(n.data)
+			k := n.removeMatchesBack(value, count-removed)
+			ls.bytes -= before - len(n.data)
+			n.recompressOnly()
+			if k > 0 {
+				removed += k
+				ls.index.add(n, -k)
+				ls.free(n)
 			}
+			n = prev
 		}
+		return
 	}
-	return 0, false
+
+	limit := -1
+	if count > 0 {
+		limit = count
+	}
+	for n := ls.head; n != nil && removed != limit; {
+		next := n.next
+		n.decompressForUse()
+		before := len(n.data)
+		k := n.removeMatches(value, limit-removed)
+		ls.bytes -= before - len(n.data)
+		n.recompressOnly()
+		if k > 0 {
+			removed += k
+			ls.index.add(n, -k)
+			ls.free(n)
+			// next is merged into n.
+			if next != nil && !next.indexed() {
+				next = n
+			}
+		}
+		n = next
+	}
+	return
 }
 
-// Size
-func (ls *QuickList) Size() (n int) {
-	for lp := ls.head; lp != nil; lp = lp.next {
-		n += lp.Size()
+// PosOptions is the options of Pos, same as Redis LPOS.
+type PosOptions struct {
+	// Rank is the rank of the first match to return, e.g. 2 means skip the first match,
+	// negative rank searches from the tail, 0 is the same as 1.
+	Rank int
+
+	// Count is the max number of matches to return, 0 means all matches.
+	Count int
+
+	// MaxLen is the max number of entries to compare, 0 means no limit.
+	MaxLen int
+}
+
+// Pos returns the indexes of entries equal to value like Redis LPOS,
+// the indexes are in the order of searching.
+func (ls *QuickList) Pos(value string, opts PosOptions) (res []int) {
+	rank := opts.Rank
+	if rank == 0 {
+		rank = 1
+	}
+	end := ls.Size()
+	if opts.MaxLen > 0 {
+		end = min(end, opts.MaxLen)
+	}
+
+	var i int
+	f := func(data []byte) bool {
+		if bytes.Equal(s2b(&value), data) {
+			switch {
+			case rank > 1:
+				rank--
+			case rank < -1:
+				rank++
+			default:
+				res = append(res, i)
+			}
+		}
+		i++
+		return opts.Count > 0 && len(res) == opts.Count
+	}
+	if rank > 0 {
+		ls.iterFront(0, end, f)
+		return
+	}
+	ls.iterBack(0, end, f)
+	for j := range res {
+		res[j] = ls.Size() - res[j] - 1
 	}
 	return
 }
 
+// Trim keeps only the entries in [start, stop] like Redis LTRIM, negative start
+// and stop count from the tail, and stop is inclusive. Nodes out of the range are
+// unlinked as a whole, and the boundary listpacks are truncated at most once.
+func (ls *QuickList) Trim(start, stop int) {
+	size := ls.Size()
+	if start < 0 {
+		start += size
+	}
+	if stop < 0 {
+		stop += size
+	}
+	start = max(start, 0)
+	if start > stop || start >= size {
+		ls.clear()
+		return
+	}
+	stop = min(stop, size-1)
+
+	ls.trimFront(start)
+	ls.trimBack(size - 1 - stop)
+}
+
+// trimFront deletes k entries from the head, k must be less than size of list.
+// The whole nodes are unlinked in a single step, and the boundary listpack is resliced
+// past the deleted entries instead of moving the rest, so evicting from a capped list
+// is O(1). The dead bytes are released when the node is dropped or its data is reallocated.
+func (ls *QuickList) trimFront(k int) {
+	if k <= 0 {
+		return
+	}
+	last := ls.head
+	for ; last.Size() <= k; last = last.next {
+		k -= last.Size()
+	}
+	if last != ls.head {
+		ls.delNodes(ls.head, last.prev)
+	}
+	if k > 0 {
+		n := ls.head
+		n.decompressForUse()
+		before := len(n.data)
+		n.data = n.data[n.seek(k):]
+		ls.bytes -= before - len(n.data)
+		n.size -= uint32(k)
+		ls.index.add(n, -k)
+		n.recompressOnly()
+		ls.free(n)
+	}
+}
+
+// trimBack deletes k entries from the tail, k must be less than size of list.
+func (ls *QuickList) trimBack(k int) {
+	if k <= 0 {
+		return
+	}
+	first := ls.tail
+	for ; first.Size() <= k; first = first.prev {
+		k -= first.Size()
+	}
+	if first != ls.tail {
+		ls.delNodes(first.next, ls.tail)
+	}
+	if k > 0 {
+		n := ls.tail
+		n.decompressForUse()
+		before := len(n.data)
+		n.data = n.data[:n.seek(n.Size()-k)]
+		ls.bytes -= before - len(n.data)
+		n.size -= uint32(k)
+		ls.index.add(n, -k)
+		n.recompressOnly()
+		ls.free(n)
+	}
+}
+
+// overCap reports whether list exceeds CapEntries or CapBytes.
+func (ls *QuickList) overCap() bool {
+	if ls.opts.CapEntries > 0 && ls.Size() > ls.opts.CapEntries {
+		return true
+	}
+	return ls.opts.CapBytes > 0 && ls.bytes > ls.opts.CapBytes
+}
+
+// evict deletes entries from end until list fits CapEntries and CapBytes,
+// the last entry is always kept. Whole nodes are dropped by trimFront or trimBack.
+// Returns the number of evicted entries.
+func (ls *QuickList) evict(end End) int {
+	if !ls.overCap() {
+		return 0
+	}
+	var k int
+	if ls.opts.CapEntries > 0 {
+		k = ls.Size() - ls.opts.CapEntries
+	}
+	if ls.opts.CapBytes > 0 && ls.bytes > ls.opts.CapBytes {
+		k = max(k, ls.bytesOver(end))
+	}
+	k = min(k, ls.Size()-1)
+	if k <= 0 {
+		return 0
+	}
+
+	if f := ls.opts.OnEvict; f != nil {
+		iter := ls.iterFront
+		if end == Right {
+			iter = ls.iterBack
+		}
+		iter(0, k, func(data []byte) bool {
+			f(string(data))
+			return false
+		})
+	}
+	if end == Left {
+		ls.trimFront(k)
+	} else {
+		ls.trimBack(k)
+	}
+	return k
+}
+
+// bytesOver returns the number of entries to delete from end to fit CapBytes.
+func (ls *QuickList) bytesOver(end End) (k int) {
+	over := ls.bytes - ls.opts.CapBytes
+	next := func(n *Node) *Node { return n.next }
+	n := ls.head
+	if end == Right {
+		next = func(n *Node) *Node { return n.prev }
+		n = ls.tail
+	}
+	// whole nodes
+	for ; n != nil && over > 0 && n.dataLen() <= over; n = next(n) {
+		over -= n.dataLen()
+		k += n.Size()
+	}
+	if n == nil || over <= 0 {
+		return k
+	}
+
+	// entries of the boundary node
+	f := func(_ []byte, _ int, startPos, endPos int) bool {
+		over -= endPos - startPos
+		k++
+		return over <= 0
+	}
+	n.decompressForUse()
+	if end == Left {
+		n.iterFront(0, -1, f)
+	} else {
+		n.iterBack(0, -1, f)
+	}
+	n.recompressOnly()
+	return k
+}
+
+// clear removes all entries and releases the nodes.
+func (ls *QuickList) clear() {
+	for n := ls.head; n != nil; n = n.next {
+		if !n.compressed() {
+			bpool.Put(n.data)
+		}
+	}
+	n := newNode()
+	ls.head, ls.tail = n, n
+	ls.index.rebuild(n)
+	ls.bytes = 0
+}
+
+// Size
+func (ls *QuickList) Size() int {
+	return ls.index.total
+}
+
+// Bytes returns the total size of listpack data of list before compression.
+func (ls *QuickList) Bytes() int {
+	return ls.bytes
+}
+
+// lsIterator is called with each entry of list. data is only valid during the call,
+// integer entries are formatted into a buffer reused by the next entry, and
+// compressed nodes are compressed again after use, copy it if needed.
 type lsIterator func(data []byte) (stop bool)
 
 func (ls *QuickList) iterFront(start, end int, f lsIterator) {
@@ -173,11 +926,13 @@ func (ls *QuickList) iterFront(start, end int, f lsIterator) {
 
 	var stop bool
 	for !stop && count > 0 && lp != nil {
+		lp.decompressForUse()
 		lp.Range(indexInternal, -1, func(data []byte, _ int) bool {
 			stop = f(data)
 			count--
 			return stop || count == 0
 		})
+		lp.recompressOnly()
 		lp = lp.next
 		indexInternal = 0
 	}
@@ -192,34 +947,38 @@ func (ls *QuickList) iterBack(start, end int, f lsIterator) {
 		return
 	}
 
-	lp := ls.tail
-	for start > lp.Size() {
-		start -= lp.Size()
-		lp = lp.prev
-		if lp == nil {
-			return
-		}
+	lp, indexInternal := ls.find(ls.Size() - start - 1)
+	if lp == nil {
+		return
 	}
+	start = lp.Size() - indexInternal - 1
 
 	var stop bool
 	for !stop && count > 0 && lp != nil {
+		lp.decompressForUse()
 		lp.RevRange(start, -1, func(data []byte, _ int) bool {
 			stop = f(data)
 			count--
 			return stop || count == 0
 		})
+		lp.recompressOnly()
 		lp = lp.prev
 		start = 0
 	}
 }
 
-// Range
+// Range calls f for entries in [start, end), negative start and end count
+// from the tail like Redis LRANGE, e.g. [0, -1] means all and [-3, -1] means the last three.
+// The data passed to f is only valid during the call, copy it if needed.
 func (ls *QuickList) Range(start, end int, f lsIterator) {
+	start, end = normalizeRange(start, end, ls.Size())
 	ls.iterFront(start, end, f)
 }
 
-// RevRange
+// RevRange is the same as Range but iterates from the tail,
+// start and end are the offsets from the tail.
 func (ls *QuickList) RevRange(start, end int, f lsIterator) {
+	start, end = normalizeRange(start, end, ls.Size())
 	ls.iterBack(start, end, f)
 }
 
@@ -229,8 +988,17 @@ var order = binary.LittleEndian
 func (ls *QuickList) MarshalBinary() ([]byte, error) {
 	data := bpool.Get(1024)[:0]
 
-	for lp := ls.head; lp != nil && lp.size > 0; lp = lp.next {
+	for lp := ls.head; lp != nil; lp = lp.next {
+		if lp.size == 0 {
+			continue
+		}
+		lp.decompressForUse()
 		data = append(data, lp.ToBytes()...)
+		lp.recompressOnly()
+	}
+	// empty list
+	if len(data) == 0 {
+		data = append(data, ls.head.ToBytes()...)
 	}
 	return data, nil
 }
@@ -242,27 +1010,23 @@ func (ls *QuickList) UnmarshalBinary(src []byte) error {
 	if len(src) < 8 {
 		return ErrUnmarshal
 	}
+	// zero value of QuickList uses DefaultOptions.
+	if ls.opts.MaxListPackSize == 0 {
+		ls.opts = DefaultOptions.normalize()
+	}
 
 	ls.head = nil
 	var last *Node
 
-	for index := 0; len(src)-index >= 8; {
-		// dataLen
-		dataLen := order.Uint32(src[index+4:])
-
-		// bound check
-		if index+8+int(dataLen) > len(src) {
-			return ErrUnmarshal
-		}
-
-		lp, err := NewFromBytes(src[index:])
+	for index := 0; index < len(src); {
+		lp, n, err := readListPack(src[index:])
 		if err != nil {
 			return err
 		}
 		node := &Node{ListPack: lp}
 
 		node.prev = last
-		index = index + 8 + int(dataLen)
+		index += n
 
 		if ls.head == nil {
 			ls.head = node
@@ -273,5 +1037,12 @@ func (ls *QuickList) UnmarshalBinary(src []byte) error {
 		ls.tail = node
 		last = node
 	}
+	ls.index.rebuild(ls.head)
+	ls.bytes = 0
+	for n := ls.head; n != nil; n = n.next {
+		ls.bytes += n.dataLen()
+		ls.compress(n)
+	}
+	ls.evict(Left)
 	return nil
 }
@@ -4,7 +4,9 @@ import (
 	"crypto/md5"
 	"fmt"
 	"math/rand/v2"
+	"slices"
 	"strconv"
+	"strings"
 	"testing"
 )
 
@@ -33,7 +35,7 @@ func TestList(t *testing.T) {
 		}
 		// check each node length
 		for cur := ls.head; cur != nil; cur = cur.next {
-			lessOrEqual(t, len(cur.data), maxListPackSize)
+			lessOrEqual(t, len(cur.data), ls.opts.MaxListPackSize)
 		}
 	})
 
@@ -50,7 +52,146 @@ func TestList(t *testing.T) {
 		}
 		// check each node length
 		for cur := ls.head; cur != nil; cur = cur.next {
-			lessOrEqual(t, len(cur.data), maxListPackSize)
+			lessOrEqual(t, len(cur.data), ls.opts.MaxListPackSize)
+		}
+	})
+
+	t.Run("options", func(t *testing.T) {
+		ls1 := NewWithOptions(Options{MaxListPackSize: 64})
+		ls2 := NewWithOptions(Options{MaxListPackEntries: 10})
+		for i := 0; i < N; i++ {
+			ls1.RPush(genKey(i))
+			ls2.LPush(genKey(i))
+		}
+		for cur := ls1.head; cur != nil; cur = cur.next {
+			lessOrEqual(t, len(cur.data), 64)
+		}
+		for cur := ls2.head; cur != nil; cur = cur.next {
+			lessOrEqual(t, cur.Size(), 10)
+		}
+		equal(t, ls1.Size(), N)
+		equal(t, ls2.Size(), N)
+
+		// fill factor
+		for _, fill := range []int{-1, -2, -5, -10, 1, 8, 100} {
+			ls := NewWithOptions(Options{Fill: fill})
+			for i := 0; i < N*10; i++ {
+				ls.RPush(genKey(i))
+			}
+			for cur := ls.head; cur != nil; cur = cur.next {
+				if fill < 0 {
+					lessOrEqual(t, len(cur.data), 4096<<(-max(fill, -5)-1))
+				} else {
+					lessOrEqual(t, cur.Size(), fill)
+					lessOrEqual(t, len(cur.data), sizeSafetyLimit)
+				}
+			}
+			equal(t, ls.Size(), N*10)
+		}
+
+		// change default options does not affect live lists.
+		ls3 := New()
+		SetMaxListPackSize(1024)
+		equal(t, ls3.opts.MaxListPackSize, 128)
+		equal(t, New().opts.MaxListPackSize, 1024)
+		SetMaxListPackSize(128)
+	})
+
+	t.Run("compress/nested", func(t *testing.T) {
+		// reading the list inside a traversal must not recompress its node.
+		ls := NewWithOptions(Options{Fill: 8, CompressDepth: 1})
+		for i := 0; i < 200; i++ {
+			ls.RPush(genKey(i))
+		}
+		var count int
+		ls.Range(0, -1, func(b []byte) bool {
+			v, _ := ls.Index(count)
+			equal(t, string(b), v)
+			count++
+			return false
+		})
+		equal(t, count, 200)
+
+		count = 0
+		for i, b := range ls.All() {
+			v, _ := ls.Index(i)
+			equal(t, string(b), v)
+			count++
+		}
+		equal(t, count, 200)
+		checkCompress(t, ls)
+
+		it := ls.Iterator(0)
+		for k := 0; it.Next(); k++ {
+			v, _ := ls.Index(it.Index())
+			equal(t, string(it.Value()), v)
+			if k%3 == 0 {
+				equal(t, it.Remove(), true)
+			}
+		}
+		equal(t, ls.Size(), 200-67)
+		for it.Prev() {
+			ls.Index(it.Index())
+		}
+		checkIndex(t, ls)
+		checkCompress(t, ls)
+
+		// release the node when stopping early.
+		it = ls.Iterator(ls.Size() / 2)
+		it.Next()
+		it.Close()
+		checkCompress(t, ls)
+	})
+
+	t.Run("compress", func(t *testing.T) {
+		for _, depth := range []int{1, 2, 5} {
+			ls := NewWithOptions(Options{MaxListPackSize: 128, CompressDepth: depth})
+			for i := 0; i < N; i++ {
+				ls.RPush(genKey(i))
+			}
+			checkCompress(t, ls)
+
+			for i := 0; i < N; i++ {
+				v, ok := ls.Index(i)
+				equal(t, genKey(i), v)
+				equal(t, true, ok)
+			}
+			checkCompress(t, ls)
+
+			for i := 0; i < N; i += 10 {
+				ok := ls.Set(i, genKey(i*2))
+				equal(t, true, ok)
+			}
+			var count int
+			ls.RevRange(0, -1, func(b []byte) bool {
+				i := N - count - 1
+				if i%10 == 0 {
+					equal(t, string(b), genKey(i*2))
+				} else {
+					equal(t, string(b), genKey(i))
+				}
+				count++
+				return false
+			})
+			equal(t, count, N)
+			checkCompress(t, ls)
+
+			// marshal
+			data, err := ls.MarshalBinary()
+			isNil(t, err)
+			ls2 := NewWithOptions(Options{CompressDepth: depth})
+			isNil(t, ls2.UnmarshalBinary(data))
+			checkCompress(t, ls2)
+			equal(t, ls2.Size(), N)
+
+			// remove from middle and both ends
+			for ls.Size() > 0 {
+				_, ok := ls.Remove(ls.Size() / 2)
+				equal(t, true, ok)
+				ls.LPop()
+				ls.RPop()
+				checkCompress(t, ls)
+			}
 		}
 	})
 
@@ -82,6 +223,51 @@ func TestList(t *testing.T) {
 		equal(t, false, ok)
 	})
 
+	t.Run("popN", func(t *testing.T) {
+		for _, opts := range []Options{
+			{},
+			{MaxListPackEntries: 8},
+			{MaxListPackSize: 64, CompressDepth: 1},
+		} {
+			ls := NewWithOptions(opts)
+			var vls []string
+			for i := 0; i < N; i++ {
+				k := genKey(i)
+				if i%3 == 0 {
+					k = strconv.Itoa(i)
+				}
+				ls.RPush(k)
+				vls = append(vls, k)
+			}
+			for len(vls) > 0 {
+				n := rand.IntN(50)
+				if rand.IntN(2) == 0 {
+					res := ls.LPopN(n)
+					n = min(n, len(vls))
+					equal(t, len(res), n)
+					for i := range res {
+						equal(t, res[i], vls[i])
+					}
+					vls = vls[n:]
+				} else {
+					res := ls.AppendRPopN([]string{"x"}, n)
+					n = min(n, len(vls))
+					equal(t, len(res), n+1)
+					equal(t, res[0], "x")
+					for i := range res[1:] {
+						equal(t, res[i+1], vls[len(vls)-i-1])
+					}
+					vls = vls[:len(vls)-n]
+				}
+				equal(t, ls.Size(), len(vls))
+				checkIndex(t, ls)
+				checkCompress(t, ls)
+			}
+			equal(t, len(ls.LPopN(10)), 0)
+			equal(t, len(ls.RPopN(-1)), 0)
+		}
+	})
+
 	t.Run("len", func(t *testing.T) {
 		ls := New()
 		for i := 0; i < N; i++ {
@@ -110,6 +296,155 @@ func TestList(t *testing.T) {
 		equal(t, false, ok)
 	})
 
+	t.Run("insert", func(t *testing.T) {
+		for _, opts := range []Options{
+			{},
+			{MaxListPackEntries: 4},
+			{MaxListPackSize: 64, CompressDepth: 1},
+			{Fill: 8, CompressDepth: 2},
+		} {
+			ls := NewWithOptions(opts)
+			var vls []string
+			for i := 0; i < N; i++ {
+				index := rand.IntN(len(vls) + 1)
+				k := genKey(i)
+				ls.Insert(index, k)
+				vls = slices.Insert(vls, index, k)
+			}
+			// insert multiple values
+			ls.Insert(N/2, "a", "b", "c")
+			vls = slices.Insert(vls, N/2, "a", "b", "c")
+			ls.Insert(-1, "end")
+			vls = append(vls, "end")
+			ls.Insert(-ls.Size()-1, "start")
+			vls = slices.Insert(vls, 0, "start")
+
+			// out of range
+			ls.Insert(ls.Size()+1, "none")
+			ls.Insert(-ls.Size()-2, "none")
+
+			var i int
+			ls.Range(0, -1, func(data []byte) bool {
+				equal(t, string(data), vls[i])
+				i++
+				return false
+			})
+			equal(t, i, len(vls))
+			checkIndex(t, ls)
+			checkCompress(t, ls)
+
+			for cur := ls.head; cur != nil; cur = cur.next {
+				if opts.MaxListPackEntries > 0 {
+					lessOrEqual(t, cur.Size(), opts.MaxListPackEntries)
+				}
+				if opts.MaxListPackSize > 0 {
+					lessOrEqual(t, cur.dataLen(), opts.MaxListPackSize)
+				}
+			}
+		}
+
+		// insert next to compressed nodes
+		ls := NewWithOptions(Options{MaxListPackSize: 256, CompressDepth: 1})
+		vls := make([]string, 0, N)
+		for i := 0; i < N; i++ {
+			ls.RPush(genKey(i))
+			vls = append(vls, genKey(i))
+		}
+		for i := 0; i < 200; i++ {
+			// before the first entry and after the last entry of interior nodes.
+			index := ls.index.offset(ls.head.next.next)
+			ls.Insert(index, "x"+genKey(i))
+			vls = slices.Insert(vls, index, "x"+genKey(i))
+			index = ls.index.offset(ls.tail.prev.prev) - 1
+			it := ls.Iterator(index)
+			it.Next()
+			it.InsertAfter("y" + genKey(i))
+			it.Close()
+			vls = slices.Insert(vls, index+1, "y"+genKey(i))
+		}
+		checkValues(t, ls, vls)
+		checkIndex(t, ls)
+		checkCompress(t, ls)
+		for cur := ls.head; cur != nil; cur = cur.next {
+			lessOrEqual(t, cur.dataLen(), 256)
+		}
+	})
+
+	t.Run("insertPivot", func(t *testing.T) {
+		for _, opts := range []Options{
+			{},
+			{MaxListPackEntries: 4},
+			{MaxListPackSize: 64, CompressDepth: 1},
+		} {
+			ls := NewWithOptions(opts)
+			vls := []string{genKey(0)}
+			ls.RPush(genKey(0))
+			for i := 1; i < N; i++ {
+				pivot := vls[rand.IntN(len(vls))]
+				index := slices.Index(vls, pivot)
+				k := genKey(i)
+
+				var n int
+				var ok bool
+				if i%2 == 0 {
+					n, ok = ls.InsertBefore(pivot, k)
+					vls = slices.Insert(vls, index, k)
+				} else {
+					n, ok = ls.InsertAfter(pivot, k)
+					vls = slices.Insert(vls, index+1, k)
+				}
+				equal(t, true, ok)
+				equal(t, n, len(vls))
+			}
+
+			// pivot not found
+			n, ok := ls.InsertBefore("none", "a")
+			equal(t, false, ok)
+			equal(t, n, 0)
+			n, ok = ls.InsertAfter("none", "a")
+			equal(t, false, ok)
+			equal(t, n, 0)
+
+			var i int
+			ls.Range(0, -1, func(data []byte) bool {
+				equal(t, string(data), vls[i])
+				i++
+				return false
+			})
+			equal(t, i, N)
+			checkIndex(t, ls)
+			checkCompress(t, ls)
+		}
+	})
+
+	t.Run("negative-index", func(t *testing.T) {
+		ls := genList(0, N)
+		for i := 1; i <= N; i++ {
+			v, ok := ls.Index(-i)
+			equal(t, genKey(N-i), v)
+			equal(t, true, ok)
+		}
+		_, ok := ls.Index(-N - 1)
+		equal(t, false, ok)
+
+		ok = ls.Set(-1, "last")
+		equal(t, true, ok)
+		v, _ := ls.Index(N - 1)
+		equal(t, v, "last")
+		ok = ls.Set(-N-1, "none")
+		equal(t, false, ok)
+
+		v, ok = ls.Remove(-N)
+		equal(t, genKey(0), v)
+		equal(t, true, ok)
+		v, ok = ls.Remove(-2)
+		equal(t, genKey(N-2), v)
+		equal(t, true, ok)
+		_, ok = ls.Remove(-N)
+		equal(t, false, ok)
+		equal(t, ls.Size(), N-2)
+	})
+
 	t.Run("remove", func(t *testing.T) {
 		ls := genList(0, N)
 		for i := 0; i < N-1; i++ {
@@ -122,9 +457,8 @@ func TestList(t *testing.T) {
 			equal(t, true, ok)
 		}
 
-		equal(t, ls.head.Size(), 0)
-		// only has 2 nodes.
-		equal(t, ls.head.next, ls.tail)
+		// empty nodes are released.
+		equal(t, ls.head, ls.tail)
 		equal(t, ls.tail.Size(), 1)
 
 		val, ok := ls.tail.Remove(-1)
@@ -132,6 +466,282 @@ func TestList(t *testing.T) {
 		equal(t, true, ok)
 	})
 
+	t.Run("merge", func(t *testing.T) {
+		for _, opts := range []Options{
+			{MaxListPackEntries: 16},
+			{MaxListPackSize: 256, CompressDepth: 1},
+		} {
+			ls := NewWithOptions(opts)
+			vls := make([]string, 0, N*10)
+			for i := 0; i < N*10; i++ {
+				ls.RPush(genKey(i))
+				vls = append(vls, genKey(i))
+			}
+			for len(vls) > N {
+				index := rand.IntN(len(vls))
+				val, ok := ls.Remove(index)
+				equal(t, val, vls[index])
+				equal(t, true, ok)
+				vls = slices.Delete(vls, index, index+1)
+			}
+
+			var nodes int
+			for cur := ls.head; cur != nil; cur = cur.next {
+				nodes++
+				if opts.MaxListPackEntries > 0 {
+					lessOrEqual(t, cur.Size(), opts.MaxListPackEntries)
+				} else {
+					lessOrEqual(t, cur.dataLen(), opts.MaxListPackSize)
+				}
+			}
+			// nodes are merged, so they are not too sparse.
+			lessOrEqual(t, nodes, N/4)
+
+			var i int
+			ls.Range(0, -1, func(data []byte) bool {
+				equal(t, string(data), vls[i])
+				i++
+				return false
+			})
+			equal(t, i, N)
+			checkIndex(t, ls)
+			checkCompress(t, ls)
+		}
+	})
+
+	t.Run("removeN", func(t *testing.T) {
+		for _, opts := range []Options{
+			{},
+			{MaxListPackEntries: 8},
+			{MaxListPackSize: 64, CompressDepth: 1},
+		} {
+			for _, count := range []int{0, 1, 5, 100, N, -1, -5, -100, -N} {
+				ls := NewWithOptions(opts)
+				var vls []string
+				for i := 0; i < N; i++ {
+					k := strconv.Itoa(rand.IntN(5))
+					ls.RPush(k)
+					vls = append(vls, k)
+				}
+
+				// remove from reference slice
+				var want int
+				switch {
+				case count >= 0:
+					vls = slices.DeleteFunc(vls, func(s string) bool {
+						if s == "3" && (count == 0 || want < count) {
+							want++
+							return true
+						}
+						return false
+					})
+				default:
+					for i := len(vls) - 1; i >= 0 && want < -count; i-- {
+						if vls[i] == "3" {
+							vls = slices.Delete(vls, i, i+1)
+							want++
+						}
+					}
+				}
+
+				n := ls.RemoveN("3", count)
+				equal(t, n, want)
+				equal(t, ls.Size(), len(vls))
+
+				var i int
+				ls.Range(0, -1, func(data []byte) bool {
+					equal(t, string(data), vls[i])
+					i++
+					return false
+				})
+				equal(t, i, len(vls))
+				checkIndex(t, ls)
+				checkCompress(t, ls)
+			}
+		}
+
+		// remove all
+		ls := New()
+		for i := 0; i < N; i++ {
+			ls.RPush("a")
+		}
+		equal(t, ls.RemoveN("a", 0), N)
+		equal(t, ls.Size(), 0)
+		equal(t, ls.head, ls.tail)
+		equal(t, ls.RemoveN("a", 0), 0)
+	})
+
+	t.Run("pos", func(t *testing.T) {
+		ls := NewWithOptions(Options{MaxListPackEntries: 8, CompressDepth: 1})
+		ls.RPush("x")
+		vls := []string{"x"}
+		for i := 1; i < N; i++ {
+			k := strconv.Itoa(rand.IntN(10))
+			ls.RPush(k)
+			vls = append(vls, k)
+		}
+		var all []int
+		for i, v := range vls {
+			if v == "3" {
+				all = append(all, i)
+			}
+		}
+		rev := slices.Clone(all)
+		slices.Reverse(rev)
+
+		equalInts := func(expected, actual []int) {
+			equal(t, len(expected), len(actual))
+			for i := range expected {
+				equal(t, expected[i], actual[i])
+			}
+		}
+		equalInts(all, ls.Pos("3", PosOptions{}))
+		equalInts(all[:1], ls.Pos("3", PosOptions{Count: 1}))
+		equalInts(all[2:5], ls.Pos("3", PosOptions{Rank: 3, Count: 3}))
+		equalInts(rev, ls.Pos("3", PosOptions{Rank: -1}))
+		equalInts(rev[1:3], ls.Pos("3", PosOptions{Rank: -2, Count: 2}))
+		equalInts(nil, ls.Pos("3", PosOptions{Rank: len(all) + 1}))
+		equalInts(nil, ls.Pos("none", PosOptions{}))
+
+		// max len
+		maxLen := all[len(all)/2] + 1
+		equalInts(all[:len(all)/2+1], ls.Pos("3", PosOptions{MaxLen: maxLen}))
+		maxLen = N - rev[len(rev)/2]
+		equalInts(rev[:len(rev)/2+1], ls.Pos("3", PosOptions{Rank: -1, MaxLen: maxLen}))
+		equalInts(nil, ls.Pos("3", PosOptions{MaxLen: all[0]}))
+	})
+
+	t.Run("move", func(t *testing.T) {
+		for _, opts := range []Options{
+			{},
+			{MaxListPackEntries: 8},
+			{MaxListPackSize: 64, CompressDepth: 1},
+		} {
+			src, dst := NewWithOptions(opts), NewWithOptions(opts)
+			var vsrc, vdst []string
+			for i := 0; i < N; i++ {
+				k := genKey(i)
+				if i%3 == 0 {
+					k = strconv.Itoa(i)
+				}
+				src.RPush(k)
+				vsrc = append(vsrc, k)
+			}
+
+			pop := func(vls *[]string, from End) (val string) {
+				if from == Left {
+					val, *vls = (*vls)[0], (*vls)[1:]
+				} else {
+					val, *vls = (*vls)[len(*vls)-1], (*vls)[:len(*vls)-1]
+				}
+				return
+			}
+			push := func(vls *[]string, val string, to End) {
+				if to == Left {
+					*vls = append([]string{val}, *vls...)
+				} else {
+					*vls = append(*vls, val)
+				}
+			}
+
+			// move between lists
+			for i := 0; i < N; i++ {
+				from, to := End(rand.IntN(2)), End(rand.IntN(2))
+				val, ok := Move(src, dst, from, to)
+				equal(t, true, ok)
+				equal(t, val, pop(&vsrc, from))
+				push(&vdst, val, to)
+			}
+			_, ok := Move(src, dst, Left, Right)
+			equal(t, false, ok)
+			equal(t, src.Size(), 0)
+
+			// rotation
+			for i := 0; i < N; i++ {
+				from, to := End(rand.IntN(2)), End(rand.IntN(2))
+				val, ok := Move(dst, dst, from, to)
+				equal(t, true, ok)
+				equal(t, val, pop(&vdst, from))
+				push(&vdst, val, to)
+			}
+			buf := []byte("prefix-")
+			for i := 0; i < N; i++ {
+				from, to := End(rand.IntN(2)), End(rand.IntN(2))
+				var ok bool
+				buf, ok = MoveAppend(dst, dst, from, to, buf[:7])
+				equal(t, true, ok)
+				val := pop(&vdst, from)
+				equal(t, string(buf), "prefix-"+val)
+				push(&vdst, val, to)
+			}
+			_, ok = MoveAppend(src, dst, Left, Right, buf)
+			equal(t, false, ok)
+
+			var i int
+			dst.Range(0, -1, func(data []byte) bool {
+				equal(t, string(data), vdst[i])
+				i++
+				return false
+			})
+			equal(t, i, N)
+			checkIndex(t, dst)
+			checkCompress(t, dst)
+		}
+	})
+
+	t.Run("trim", func(t *testing.T) {
+		for _, opts := range []Options{
+			{},
+			{MaxListPackEntries: 8},
+			{MaxListPackSize: 64, CompressDepth: 1},
+		} {
+			for _, r := range [][2]int{
+				{0, -1}, {0, 0}, {1, -2}, {-1, -1}, {-10, -1}, {100, 200},
+				{N / 2, N}, {-N * 2, 10}, {10, 5}, {N, -1}, {0, -N - 1},
+			} {
+				ls := NewWithOptions(opts)
+				var vls []string
+				for i := 0; i < N; i++ {
+					ls.RPush(genKey(i))
+					vls = append(vls, genKey(i))
+				}
+
+				// trim reference slice
+				start, stop := r[0], r[1]
+				if start < 0 {
+					start += N
+				}
+				if stop < 0 {
+					stop += N
+				}
+				start, stop = max(start, 0), min(stop, N-1)
+				if start > stop {
+					vls = vls[:0]
+				} else {
+					vls = vls[start : stop+1]
+				}
+
+				ls.Trim(r[0], r[1])
+				equal(t, ls.Size(), len(vls))
+
+				var i int
+				ls.Range(0, -1, func(data []byte) bool {
+					equal(t, string(data), vls[i])
+					i++
+					return false
+				})
+				equal(t, i, len(vls))
+				checkIndex(t, ls)
+				checkCompress(t, ls)
+
+				// still works after trim
+				ls.RPush("a")
+				ls.LPush("b")
+				equal(t, ls.Size(), len(vls)+2)
+			}
+		}
+	})
+
 	t.Run("removeFirst", func(t *testing.T) {
 		ls := genList(0, N)
 
@@ -163,10 +773,8 @@ func TestList(t *testing.T) {
 			}
 		}
 
-		equal(t, ls.head.Size(), 0)
-
-		// only has 2 nodes.
-		equal(t, ls.head.next, ls.tail)
+		// empty nodes are released.
+		equal(t, ls.head, ls.tail)
 		equal(t, ls.tail.Size(), 1)
 
 		val, ok := ls.tail.Remove(-1)
@@ -189,6 +797,44 @@ func TestList(t *testing.T) {
 			equal(t, true, ok)
 		}
 
+		// zero value
+		var ls5 QuickList
+		isNil(t, ls5.UnmarshalBinary(data))
+		equal(t, ls5.opts.MaxListPackSize, DefaultOptions.MaxListPackSize)
+		nodes := func() (n int) {
+			for cur := ls5.head; cur != nil; cur = cur.next {
+				n++
+			}
+			return
+		}
+		before := nodes()
+		for i := 0; i < 100; i++ {
+			ls5.RPush(genKey(i))
+		}
+		lessOrEqual(t, nodes()-before, 10)
+		equal(t, ls5.Size(), N+100)
+		checkIndex(t, &ls5)
+
+		// bytes are counted with compressed nodes
+		opts := Options{MaxListPackSize: 128, CompressDepth: 1}
+		ls3 := NewWithOptions(opts)
+		for i := 0; i < N; i++ {
+			ls3.RPush(genKey(i))
+		}
+		data3, _ := ls3.MarshalBinary()
+		ls4 := NewWithOptions(opts)
+		isNil(t, ls4.UnmarshalBinary(data3))
+		equal(t, ls4.Bytes(), ls3.Bytes())
+		checkIndex(t, ls4)
+		checkCompress(t, ls4)
+
+		// empty list
+		data, err = New().MarshalBinary()
+		isNil(t, err)
+		err = ls2.UnmarshalBinary(data)
+		isNil(t, err)
+		equal(t, ls2.Size(), 0)
+
 		// unmarshal error
 		data = md5.New().Sum(data)
 		err = ls2.UnmarshalBinary(data)
@@ -198,6 +844,17 @@ func TestList(t *testing.T) {
 		data = []byte{1, 1, 1, 1}
 		err = ls2.UnmarshalBinary(data)
 		isNotNil(t, err)
+
+		// legacy format without integer encoding
+		data = []byte("\x04\x00\x00\x00\x1a\x00\x00\x00\x02ab\x03\x05hello\x06\x01x\x02\n1234567890\v")
+		err = ls2.UnmarshalBinary(data)
+		isNil(t, err)
+		checkValues(t, ls2, []string{"ab", "hello", "x", "1234567890"})
+		checkIndex(t, ls2)
+
+		// legacy format truncated
+		err = ls2.UnmarshalBinary(data[:len(data)-1])
+		isNotNil(t, err)
 	})
 
 	t.Run("range", func(t *testing.T) {
@@ -218,9 +875,26 @@ func TestList(t *testing.T) {
 		ls.Range(1, 1, func(s []byte) bool {
 			panic("should not call")
 		})
-		ls.Range(-1, -1, func(s []byte) bool {
+		ls.Range(-1, -2, func(s []byte) bool {
 			panic("should not call")
 		})
+
+		// negative range
+		count = 0
+		ls.Range(-3, -1, func(s []byte) bool {
+			equal(t, string(s), genKey(N-3+count))
+			count++
+			return false
+		})
+		equal(t, count, 3)
+
+		count = 0
+		ls.Range(-N*2, 2, func(s []byte) bool {
+			equal(t, string(s), genKey(count))
+			count++
+			return false
+		})
+		equal(t, count, 2)
 	})
 
 	t.Run("revrange", func(t *testing.T) {
@@ -241,10 +915,221 @@ func TestList(t *testing.T) {
 		ls.RevRange(1, 1, func(s []byte) bool {
 			panic("should not call")
 		})
-		ls.RevRange(-1, -1, func(s []byte) bool {
+		ls.RevRange(-1, -2, func(s []byte) bool {
 			panic("should not call")
 		})
+
+		// negative range
+		count = 0
+		ls.RevRange(-3, -1, func(s []byte) bool {
+			equal(t, string(s), genKey(2-count))
+			count++
+			return false
+		})
+		equal(t, count, 3)
+	})
+
+	t.Run("range/integer", func(t *testing.T) {
+		// integer entries share a buffer, the data must be copied to keep it.
+		ls := New()
+		ls.RPush("1", "2", "3")
+		var res []string
+		ls.Range(0, -1, func(s []byte) bool {
+			res = append(res, string(s))
+			return false
+		})
+		equal(t, strings.Join(res, ","), "1,2,3")
+
+		res = res[:0]
+		ls.RevRange(0, -1, func(s []byte) bool {
+			res = append(res, string(s))
+			return false
+		})
+		equal(t, strings.Join(res, ","), "3,2,1")
+
+		res = res[:0]
+		ls.head.Range(0, -1, func(s []byte, _ int) bool {
+			res = append(res, string(s))
+			return false
+		})
+		equal(t, strings.Join(res, ","), "1,2,3")
+	})
+
+	t.Run("bytes", func(t *testing.T) {
+		ls := NewWithOptions(Options{MaxListPackEntries: 16, CompressDepth: 1})
+		for i := 0; i < N; i++ {
+			ls.RPushBytes([]byte(genKey(i)))
+			ls.LPushBytes([]byte(genKey(i)))
+		}
+		ls.RPushBytes([]byte("123"), []byte{})
+		equal(t, ls.Size(), N*2+2)
+
+		buf := []byte("prefix-")
+		b, ok := ls.IndexBytes(0, buf)
+		equal(t, ok, true)
+		equal(t, string(b), "prefix-"+genKey(N-1))
+		b, _ = ls.IndexBytes(-2, nil)
+		equal(t, string(b), "123")
+		equal(t, string(buf), "prefix-")
+
+		// empty entry
+		b, ok = ls.IndexBytes(-1, buf)
+		equal(t, ok, true)
+		equal(t, string(b), "prefix-")
+
+		// out of range keeps dst
+		b, ok = ls.IndexBytes(N*2+2, nil)
+		equal(t, ok, false)
+		equal(t, b == nil, true)
+		b, ok = ls.IndexBytes(-N*2-3, buf)
+		equal(t, ok, false)
+		equal(t, string(b), "prefix-")
+		equal(t, cap(b), cap(buf))
+
+		b, ok = ls.RPopAppend(buf[:0])
+		equal(t, ok, true)
+		equal(t, len(b), 0)
+		b, ok = ls.RPopAppend(b)
+		equal(t, ok, true)
+		equal(t, string(b), "123")
+
+		for i := N - 1; i >= 0; i-- {
+			b, ok = ls.LPopAppend(b[:0])
+			equal(t, ok, true)
+			equal(t, string(b), genKey(i))
+		}
+		for i := 0; i < N; i++ {
+			b, ok = ls.LPopAppend(b[:0])
+			equal(t, ok, true)
+			equal(t, string(b), genKey(i))
+		}
+		b, ok = ls.LPopAppend(b[:0])
+		equal(t, ok, false)
+		equal(t, len(b), 0)
+		checkIndex(t, ls)
+
+		// reuse buffer without allocation
+		ls = NewWithOptions(Options{MaxListPackEntries: 16})
+		for i := 0; i < N; i++ {
+			ls.RPush(genKey(i))
+		}
+		buf = make([]byte, 0, 64)
+		allocs := testing.AllocsPerRun(100, func() {
+			buf, _ = ls.IndexBytes(N/2, buf[:0])
+		})
+		equal(t, allocs, float64(0))
 	})
+
+	t.Run("capped", func(t *testing.T) {
+		var evicted []string
+		onEvict := func(v string) { evicted = append(evicted, v) }
+
+		// entries
+		ls := NewWithOptions(Options{MaxListPackEntries: 8, CapEntries: 100, OnEvict: onEvict})
+		for i := 0; i < N; i++ {
+			ls.RPush(genKey(i))
+			lessOrEqual(t, ls.Size(), 100)
+		}
+		equal(t, ls.Size(), 100)
+		v, _ := ls.Index(0)
+		equal(t, v, genKey(N-100))
+		equal(t, len(evicted), N-100)
+		for i, v := range evicted {
+			equal(t, v, genKey(i))
+		}
+		checkIndex(t, ls)
+
+		// push to head evicts from tail
+		evicted = evicted[:0]
+		ls.LPush("a", "b")
+		equal(t, ls.Size(), 100)
+		equal(t, evicted[0], genKey(N-1))
+		equal(t, evicted[1], genKey(N-2))
+		v, _ = ls.Index(0)
+		equal(t, v, "b")
+
+		// insert evicts from head
+		evicted = evicted[:0]
+		ls.Insert(50, "x", "y")
+		equal(t, ls.Size(), 100)
+		equal(t, evicted[0], "b")
+		equal(t, evicted[1], "a")
+		v, _ = ls.Index(48)
+		equal(t, v, "x")
+		checkIndex(t, ls)
+
+		// bytes
+		evicted = evicted[:0]
+		ls = NewWithOptions(Options{MaxListPackEntries: 8, CapBytes: 1000, CompressDepth: 1, OnEvict: onEvict})
+		for i := 0; i < N; i++ {
+			ls.RPush(genKey(i))
+			lessOrEqual(t, ls.Bytes(), 1000)
+		}
+		equal(t, ls.Bytes() > 1000-entrySize(genKey(0)), true)
+		equal(t, len(evicted)+ls.Size(), N)
+		v, _ = ls.Index(0)
+		equal(t, v, genKey(len(evicted)))
+		checkIndex(t, ls)
+		checkCompress(t, ls)
+
+		// a large entry drops whole nodes but is kept itself
+		large := string(make([]byte, 2000))
+		ls.LPush(large)
+		equal(t, ls.Size(), 1)
+		equal(t, ls.head, ls.tail)
+		v, _ = ls.Index(0)
+		equal(t, v, large)
+		equal(t, len(evicted), N)
+		checkIndex(t, ls)
+
+		// move
+		src := genList(0, 10)
+		dst := NewWithOptions(Options{CapEntries: 5})
+		for i := 0; i < 10; i++ {
+			Move(src, dst, Left, Right)
+		}
+		equal(t, dst.Size(), 5)
+		v, _ = dst.Index(0)
+		equal(t, v, genKey(5))
+
+		// unmarshal
+		data, _ := genList(0, N).MarshalBinary()
+		ls = NewWithOptions(Options{CapEntries: 10})
+		isNil(t, ls.UnmarshalBinary(data))
+		equal(t, ls.Size(), 10)
+		v, _ = ls.Index(0)
+		equal(t, v, genKey(N-10))
+
+		// iterator keeps its position
+		ls = NewWithOptions(Options{MaxListPackEntries: 4, CapEntries: 20})
+		for i := 0; i < 20; i++ {
+			ls.RPush(genKey(i))
+		}
+		it := ls.Iterator(10)
+		it.Next()
+		equal(t, it.InsertAfter("z"), true)
+		equal(t, ls.Size(), 20)
+		it.Next()
+		equal(t, string(it.Value()), genKey(11))
+		equal(t, it.Index(), 11)
+	})
+}
+
+// checkCompress checks that nodes within depth are not compressed,
+// and the others are compressed unless they are too small.
+func checkCompress(t *testing.T, ls *QuickList) {
+	var nodes []*Node
+	for n := ls.head; n != nil; n = n.next {
+		nodes = append(nodes, n)
+	}
+	depth := ls.opts.CompressDepth
+	for i, n := range nodes {
+		if depth == 0 || i < depth || i >= len(nodes)-depth {
+			equal(t, false, n.compressed())
+		} else if len(n.data) >= 100 {
+			equal(t, true, n.compressed())
+		}
+	}
 }
 
 func FuzzList(f *testing.F) {
@@ -252,7 +1137,7 @@ func FuzzList(f *testing.F) {
 	vls := make([]string, 0, 4096)
 
 	f.Fuzz(func(t *testing.T, key string) {
-		switch rand.IntN(15) {
+		switch rand.IntN(16) {
 		// RPush
 		case 0, 1, 2:
 			k := strconv.Itoa(rand.Int())
@@ -351,6 +1236,12 @@ func FuzzList(f *testing.F) {
 				i++
 				return false
 			})
+
+		// Insert
+		case 15:
+			index := rand.IntN(len(vls) + 1)
+			ls.Insert(index, key)
+			vls = slices.Insert(vls, index, key)
 		}
 	})
 }
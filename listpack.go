@@ -4,13 +4,10 @@ import (
 	"bytes"
 	"encoding/binary"
 	"slices"
+	"strconv"
 )
 
-var (
-	maxListPackSize = 1024 * 1024
-
-	bpool = NewBufferPool()
-)
+var bpool = NewBufferPool()
 
 // ListPack is a lists of strings serialization format on Redis.
 /*
@@ -20,14 +17,24 @@ var (
 	+--------+--------+-----+--------+
 	    |
 	  entry0 content:
-	+------------+--------------+---------------------+
-	|  data_len  |     data     |      entry_len      |
-	+------------+--------------+---------------------+
-	|<- varint ->|<- data_len ->|<- varint(reverse) ->|
-	|<------- entry_len ------->|
+	+--------------------+--------------+---------------------+
+	| data_len<<1 | enc  |     data     |      entry_len      |
+	+--------------------+--------------+---------------------+
+	|<----- varint ----->|<- data_len ->|<- varint(reverse) ->|
+	|<----------- entry_len ----------->|
 
 	Using this structure, it is fast to iterate from both sides.
+
+	enc is the encoding of data:
+	0: string, data is the raw bytes.
+	1: integer, data is the varint of the integer, only used for
+	   strings that round-trip exactly, e.g. "1234567890" but not "0123".
 */
+const (
+	encString = 0
+	encInt    = 1
+)
+
 type ListPack struct {
 	size uint32
 	data []byte
@@ -45,67 +52,78 @@ func (lp *ListPack) Size() int {
 type lpIterator func(data []byte, index int, startPos, endPos int) (stop bool)
 
 func (lp *ListPack) iterFront(start, end int, f lpIterator) {
-	if end == -1 {
+	if end == -1 || end > lp.Size() {
 		end = lp.Size()
 	}
-	var index int
-	for i := 0; i < end && index < len(lp.data); i++ {
+	if start < 0 || start >= end {
+		return
+	}
+	var index = lp.seek(start)
+	var buf []byte
+	for i := start; i < end && index < len(lp.data); i++ {
 		//
-		//    index     dataStartPos    dataEndPos            indexNext
-		//      |            |              |                     |
-		//      +------------+--------------+---------------------+-----+
-		//  --> |  data_len  |     data     |      entry_len      | ... |
-		//      +------------+--------------+---------------------+-----+
-		//      |<--- n ---->|<- data_len ->|<-- size_entry_len ->|
+		//    index                                       indexNext
+		//      |                                             |
+		//      +------------+--------------+-----------+-----+
+		//  --> |  data_len  |     data     | entry_len | ... |
+		//      +------------+--------------+-----------+-----+
 		//
-		dataLen, n := binary.Uvarint(lp.data[index:])
-		indexNext := index + n + int(dataLen) + +SizeUvarint(dataLen+uint64(n))
-
-		if i >= start {
-			dataStartPos := index + n
-			dataEndPos := dataStartPos + int(dataLen)
-
-			data := lp.data[dataStartPos:dataEndPos]
-			if f(data, i, index, indexNext) {
-				return
-			}
+		data, size := readEntry(lp.data[index:], &buf)
+		indexNext := index + size
+		if f(data, i, index, indexNext) {
+			return
 		}
 		index = indexNext
 	}
 }
 
 func (lp *ListPack) iterBack(start, end int, f lpIterator) {
-	if end == -1 {
+	if end == -1 || end > lp.Size() {
 		end = lp.Size()
 	}
-	var index = len(lp.data)
-	for i := 0; i < end && index > 0; i++ {
+	if start < 0 || start >= end {
+		return
+	}
+	var index = lp.seek(lp.Size() - start)
+	var buf []byte
+	for i := start; i < end && index > 0; i++ {
 		//
-		//    indexNext  dataStartPos    dataEndPos               index
-		//        |            |              |                     |
-		//  +-----+------------+--------------+---------------------+
-		//  | ... |  data_len  |     data     |      entry_len      | <--
-		//  +-----+------------+--------------+---------------------+
-		//        |<--- n ---->|<- data_len ->|<-- size_entry_len ->|
+		//    indexNext                                  index
+		//        |                                        |
+		//  +-----+------------+--------------+------------+
+		//  | ... |  data_len  |     data     | entry_len  | <--
+		//  +-----+------------+--------------+------------+
 		//        |<------ entry_len -------->|
 		//
 		entryLen, sizeEntryLen := uvarintReverse(lp.data[:index])
 		indexNext := index - int(entryLen) - sizeEntryLen
 
-		if i >= start {
-			dataLen, n := binary.Uvarint(lp.data[indexNext:])
-			dataStartPos := indexNext + n
-			dataEndPos := dataStartPos + int(dataLen)
-
-			data := lp.data[dataStartPos:dataEndPos]
-			if f(data, i, indexNext, index) {
-				return
-			}
+		data, _ := readEntry(lp.data[indexNext:index], &buf)
+		if f(data, i, indexNext, index) {
+			return
 		}
 		index = indexNext
 	}
 }
 
+// seek returns the start position of the entry at index, index == size
+// returns the end of data. It walks from the nearer side of listpack.
+func (lp *ListPack) seek(index int) int {
+	if index <= lp.Size()/2 {
+		var pos int
+		for i := 0; i < index; i++ {
+			pos += entrySizeAt(lp.data[pos:])
+		}
+		return pos
+	}
+	var pos = len(lp.data)
+	for i := lp.Size(); i > index; i-- {
+		entryLen, n := uvarintReverse(lp.data[:pos])
+		pos -= int(entryLen) + n
+	}
+	return pos
+}
+
 // find quickly locates the element based on index.
 // When the target index is in the first half, use forward traversal;
 // otherwise, use reverse traversal.
@@ -130,9 +148,10 @@ func (lp *ListPack) find(index int, fn func(old []byte, index int, startPos, end
 // Insert datas into listpack.
 // index = 0: same as `LPush`
 // index = -1: same as `RPush`
+// Other negative index counts from the tail, e.g. -2 inserts before the last entry.
 func (lp *ListPack) Insert(index int, datas ...string) {
-	if index == -1 {
-		index = lp.Size()
+	if index < 0 {
+		index += lp.Size() + 1
 	}
 
 	// rpush
@@ -145,7 +164,7 @@ func (lp *ListPack) Insert(index int, datas ...string) {
 	}
 
 	// insert
-	if index < lp.Size() {
+	if index >= 0 && index < lp.Size() {
 		var pos int
 		lp.find(index, func(_ []byte, _, startPos, _ int) {
 			pos = startPos
@@ -161,26 +180,28 @@ func (lp *ListPack) Insert(index int, datas ...string) {
 	}
 }
 
+// Set updates the entry at index, negative index counts from the tail.
 func (lp *ListPack) Set(index int, data string) (ok bool) {
-	if index == -1 {
-		index = lp.Size() - 1
+	if index < 0 {
+		index += lp.Size()
 	}
-	lp.find(index, func(old []byte, _, startPos, endPos int) {
-		if len(data) == len(old) {
-			copy(old, data)
+	lp.find(index, func(_ []byte, _, startPos, endPos int) {
+		alloc := appendEntry(nil, data)
+		if len(alloc) == endPos-startPos {
+			copy(lp.data[startPos:endPos], alloc)
 		} else {
-			alloc := appendEntry(nil, data)
 			lp.data = slices.Replace(lp.data, startPos, endPos, alloc...)
-			bpool.Put(alloc)
 		}
+		bpool.Put(alloc)
 		ok = true
 	})
 	return
 }
 
+// Remove deletes the entry at index, negative index counts from the tail.
 func (lp *ListPack) Remove(index int) (val string, ok bool) {
-	if index == -1 {
-		index = lp.Size() - 1
+	if index < 0 {
+		index += lp.Size()
 	}
 	lp.find(index, func(data []byte, _, startPos, endPos int) {
 		val = string(data)
@@ -191,46 +212,226 @@ func (lp *ListPack) Remove(index int) (val string, ok bool) {
 	return
 }
 
+// removeAppend deletes the entry at index which must be in range, and appends it to dst.
+func (lp *ListPack) removeAppend(dst []byte, index int) []byte {
+	lp.find(index, func(data []byte, _, startPos, endPos int) {
+		dst = append(dst, data...)
+		lp.data = slices.Delete(lp.data, startPos, endPos)
+		lp.size--
+	})
+	return dst
+}
+
 func (lp *ListPack) RemoveFirst(data string) (res int, ok bool) {
-	lp.iterFront(0, -1, func(old []byte, index int, startPos, endPos int) bool {
+	index, startPos, endPos := lp.findFirst(data)
+	if index < 0 {
+		return 0, false
+	}
+	lp.data = slices.Delete(lp.data, startPos, endPos)
+	lp.size--
+	return index, true
+}
+
+// popRaw removes the first or last entry and appends its encoded bytes to dst.
+func (lp *ListPack) popRaw(dst []byte, left bool) []byte {
+	if left {
+		end := entrySizeAt(lp.data)
+		dst = append(dst, lp.data[:end]...)
+		lp.data = slices.Delete(lp.data, 0, end)
+	} else {
+		entryLen, n := uvarintReverse(lp.data)
+		start := len(lp.data) - int(entryLen) - n
+		dst = append(dst, lp.data[start:]...)
+		lp.data = lp.data[:start]
+	}
+	lp.size--
+	return dst
+}
+
+// pushRaw inserts the encoded entry at the head or tail.
+func (lp *ListPack) pushRaw(entry []byte, left bool) {
+	if left {
+		lp.data = slices.Insert(lp.data, 0, entry...)
+	} else {
+		lp.data = append(lp.data, entry...)
+	}
+	lp.size++
+}
+
+// removeMatches deletes entries equal to data in one compacting pass,
+// it removes at most `limit` matches, limit < 0 means no limit.
+// Returns the number of removed entries.
+func (lp *ListPack) removeMatches(data string, limit int) (removed int) {
+	// entries before w are compacted, entries after r are not scanned.
+	w, r := -1, len(lp.data)
+	lp.iterFront(0, -1, func(old []byte, _ int, startPos, endPos int) bool {
 		if bytes.Equal(s2b(&data), old) {
-			lp.data = slices.Delete(lp.data, startPos, endPos)
-			lp.size--
-			res, ok = index, true
+			if w < 0 {
+				w = startPos
+			}
+			removed++
+			r = endPos
+			return removed == limit
+		}
+		if w >= 0 {
+			w += copy(lp.data[w:], lp.data[startPos:endPos])
 		}
-		return ok
+		r = endPos
+		return false
 	})
+	if w < 0 {
+		return 0
+	}
+	w += copy(lp.data[w:], lp.data[r:])
+	lp.data = lp.data[:w]
+	lp.size -= uint32(removed)
 	return
 }
 
+// removeMatchesBack is the same as removeMatches but scans from the tail.
+func (lp *ListPack) removeMatchesBack(data string, limit int) (removed int) {
+	// entries after w are compacted, entries before r are not scanned.
+	w, r := -1, 0
+	lp.iterBack(0, -1, func(old []byte, _ int, startPos, endPos int) bool {
+		if bytes.Equal(s2b(&data), old) {
+			if w < 0 {
+				w = endPos
+			}
+			removed++
+			r = startPos
+			return removed == limit
+		}
+		if w >= 0 {
+			w -= endPos - startPos
+			copy(lp.data[w:], lp.data[startPos:endPos])
+		}
+		r = startPos
+		return false
+	})
+	if w < 0 {
+		return 0
+	}
+	n := copy(lp.data[r:], lp.data[w:])
+	lp.data = lp.data[:r+n]
+	lp.size -= uint32(removed)
+	return
+}
+
+// findFirst returns the index and position of the first entry equal to data,
+// index is -1 if not found.
+func (lp *ListPack) findFirst(data string) (index, startPos, endPos int) {
+	index = -1
+	lp.iterFront(0, -1, func(old []byte, i int, start, end int) bool {
+		if bytes.Equal(s2b(&data), old) {
+			index, startPos, endPos = i, start, end
+			return true
+		}
+		return false
+	})
+	return
+}
+
+// Range calls fn for entries in [start, end), negative start and end count
+// from the tail like Redis LRANGE, e.g. [0, -1] means all and [-3, -1] means the last three.
+// The data passed to fn is only valid during the call, copy it if needed.
 func (lp *ListPack) Range(start, end int, fn func(data []byte, index int) (stop bool)) {
+	start, end = normalizeRange(start, end, lp.Size())
 	lp.iterFront(start, end, func(data []byte, index int, _, _ int) bool {
 		return fn(data, index)
 	})
 }
 
+// RevRange is the same as Range but iterates from the tail,
+// start and end are the offsets from the tail.
 func (lp *ListPack) RevRange(start, end int, fn func(data []byte, index int) (stop bool)) {
+	start, end = normalizeRange(start, end, lp.Size())
 	lp.iterBack(start, end, func(data []byte, index int, _, _ int) bool {
 		return fn(data, index)
 	})
 }
 
-// encode data to [data_len, data, entry_len].
+// encode data to [data_len<<1 | enc, data, entry_len].
 func appendEntry(dst []byte, data string) []byte {
 	if dst == nil {
-		dst = bpool.Get(maxListPackSize)[:0]
+		dst = bpool.Get(len(data) + 2*binary.MaxVarintLen64)[:0]
 	}
 	before := len(dst)
-	dst = appendUvarint(dst, len(data), false)
-	dst = append(dst, data...)
+	if v, ok := parseInt(data); ok {
+		dst = appendUvarint(dst, sizeVarint(v)<<1|encInt, false)
+		dst = binary.AppendVarint(dst, v)
+	} else {
+		dst = appendUvarint(dst, len(data)<<1|encString, false)
+		dst = append(dst, data...)
+	}
 	return appendUvarint(dst, len(dst)-before, true)
 }
 
+// entrySize returns the size of the entry encoded by appendEntry.
+func entrySize(data string) int {
+	var dataLen, header int
+	if v, ok := parseInt(data); ok {
+		dataLen = sizeVarint(v)
+		header = SizeUvarint(uint64(dataLen<<1 | encInt))
+	} else {
+		dataLen = len(data)
+		header = SizeUvarint(uint64(dataLen<<1 | encString))
+	}
+	return header + dataLen + SizeUvarint(uint64(header+dataLen))
+}
+
+// maxEntrySize returns the size of an entry of n bytes encoded as string,
+// it is not less than entrySize since the integer encoding is never longer.
+func maxEntrySize(n int) int {
+	header := SizeUvarint(uint64(n<<1 | encString))
+	return header + n + SizeUvarint(uint64(header+n))
+}
+
+// readEntry decodes the entry at the beginning of b, returns the data
+// and size of the entry. Integers are formatted to canonical string in buf.
+func readEntry(b []byte, buf *[]byte) ([]byte, int) {
+	header, n := binary.Uvarint(b)
+	dataLen := int(header >> 1)
+	data := b[n : n+dataLen]
+	size := n + dataLen + SizeUvarint(uint64(n+dataLen))
+
+	if header&1 == encInt {
+		v, _ := binary.Varint(data)
+		*buf = strconv.AppendInt((*buf)[:0], v, 10)
+		data = *buf
+	}
+	return data, size
+}
+
+// appendValue appends the value of the entry at the beginning of b to dst,
+// b may start at the end of dst, it is read before being overwritten.
+func appendValue(dst, b []byte) []byte {
+	header, n := binary.Uvarint(b)
+	data := b[n : n+int(header>>1)]
+	if header&1 == encInt {
+		v, _ := binary.Varint(data)
+		return strconv.AppendInt(dst, v, 10)
+	}
+	return append(dst, data...)
+}
+
+// entrySizeAt returns size of the entry at the beginning of b.
+func entrySizeAt(b []byte) int {
+	header, n := binary.Uvarint(b)
+	dataLen := n + int(header>>1)
+	return dataLen + SizeUvarint(uint64(dataLen))
+}
+
+// lpMagic is written before each listpack by ToBytes. Data without it is in the
+// legacy format whose entry header is data_len only, it is migrated when loaded.
+// Read as a legacy size it would be over 4 billion entries, so it never collides.
+const lpMagic uint32 = 0xff024c51
+
 // ToBytes
 func (lp *ListPack) ToBytes() []byte {
-	data := bpool.Get(len(lp.data) + 4 + 4)[:0]
+	data := bpool.Get(len(lp.data) + 4 + 4 + 4)[:0]
 
-	// append [size, len_data, data]
+	// append [magic, size, len_data, data]
+	data = order.AppendUint32(data, lpMagic)
 	data = order.AppendUint32(data, lp.size)
 	data = order.AppendUint32(data, uint32(len(lp.data)))
 	data = append(data, lp.data...)
@@ -240,14 +441,60 @@ func (lp *ListPack) ToBytes() []byte {
 
 // NewFromBytes
 func NewFromBytes(data []byte) (*ListPack, error) {
-	if len(data) < 8 {
-		return nil, ErrUnmarshal
+	lp, _, err := readListPack(data)
+	return lp, err
+}
+
+// readListPack decodes the listpack at the beginning of src, returns it
+// and the number of bytes read.
+func readListPack(src []byte) (*ListPack, int, error) {
+	var n int
+	legacy := len(src) < 4 || order.Uint32(src) != lpMagic
+	if !legacy {
+		n = 4
 	}
-	lp := NewListPack()
+	if len(src)-n < 8 {
+		return nil, 0, ErrUnmarshal
+	}
+	size := order.Uint32(src[n:])
+	dataLen := int(order.Uint32(src[n+4:]))
+	n += 8
+	if dataLen > len(src)-n {
+		return nil, 0, ErrUnmarshal
+	}
+	// limit the capacity so that appending does not overwrite the following data.
+	data := src[n : n+dataLen : n+dataLen]
+	n += dataLen
 
-	lp.size = order.Uint32(data)
-	dataLen := order.Uint32(data[4:])
-	lp.data = data[8 : 8+dataLen]
+	if legacy {
+		lp, err := migrateListPack(size, data)
+		return lp, n, err
+	}
+	return &ListPack{size: size, data: data}, n, nil
+}
 
+// migrateListPack converts the legacy entries [data_len, data, entry_len]
+// to the current encoding.
+func migrateListPack(size uint32, data []byte) (*ListPack, error) {
+	lp := NewListPack()
+	for i := uint32(0); i < size; i++ {
+		dataLen, n := binary.Uvarint(data)
+		if n <= 0 || dataLen > uint64(len(data)-n) {
+			return nil, ErrUnmarshal
+		}
+		end := n + int(dataLen)
+		lp.data = appendEntry(lp.data, b2s(data[n:end]))
+
+		// skip entry_len
+		end += SizeUvarint(uint64(end))
+		if end > len(data) {
+			return nil, ErrUnmarshal
+		}
+		data = data[end:]
+	}
+	if len(data) > 0 {
+		return nil, ErrUnmarshal
+	}
+	lp.size = size
 	return lp, nil
 }
@@ -3,6 +3,7 @@ package quicklist
 import (
 	"fmt"
 	"math/rand/v2"
+	"strings"
 	"testing"
 )
 
@@ -142,6 +143,43 @@ func TestListPack(t *testing.T) {
 		equal(t, true, ok)
 	})
 
+	t.Run("removeMatches", func(t *testing.T) {
+		keys := []string{"a", "b", "a", "123", "a", "c", "123", "a"}
+		for _, tc := range []struct {
+			data  string
+			limit int
+			back  bool
+			want  []string
+		}{
+			{"a", -1, false, []string{"b", "123", "c", "123"}},
+			{"a", 1, false, []string{"b", "a", "123", "a", "c", "123", "a"}},
+			{"a", 2, false, []string{"b", "123", "a", "c", "123", "a"}},
+			{"123", -1, false, []string{"a", "b", "a", "a", "c", "a"}},
+			{"none", -1, false, keys},
+			{"a", -1, true, []string{"b", "123", "c", "123"}},
+			{"a", 1, true, []string{"a", "b", "a", "123", "a", "c", "123"}},
+			{"a", 2, true, []string{"a", "b", "a", "123", "c", "123"}},
+			{"123", 1, true, []string{"a", "b", "a", "123", "a", "c", "a"}},
+			{"none", -1, true, keys},
+		} {
+			lp := NewListPack()
+			lp.Insert(-1, keys...)
+
+			var n int
+			if tc.back {
+				n = lp.removeMatchesBack(tc.data, tc.limit)
+			} else {
+				n = lp.removeMatches(tc.data, tc.limit)
+			}
+			equal(t, n, len(keys)-len(tc.want))
+			equal(t, lp.Size(), len(tc.want))
+
+			lp2 := NewListPack()
+			lp2.Insert(-1, tc.want...)
+			equalBytes(t, lp.data, lp2.data)
+		}
+	})
+
 	t.Run("set", func(t *testing.T) {
 		lp := genListPack(0, N)
 
@@ -245,6 +283,101 @@ func TestListPack(t *testing.T) {
 		}
 	})
 
+	t.Run("negative-index", func(t *testing.T) {
+		lp := genListPack(0, N)
+
+		ok := lp.Set(-N, "first")
+		equal(t, true, ok)
+		ok = lp.Set(-N-1, "none")
+		equal(t, false, ok)
+
+		val, ok := lp.Remove(-N)
+		equal(t, val, "first")
+		equal(t, true, ok)
+		val, ok = lp.Remove(-2)
+		equal(t, val, genKey(N-2))
+		equal(t, true, ok)
+
+		// insert before the last entry
+		lp.Insert(-2, "test")
+		val, _ = lp.Remove(-2)
+		equal(t, val, "test")
+
+		// range the last three
+		var keys []string
+		lp.Range(-3, -1, func(data []byte, index int) bool {
+			keys = append(keys, string(data))
+			return false
+		})
+		equal(t, len(keys), 3)
+		equal(t, keys[0], genKey(N-4))
+		equal(t, keys[2], genKey(N-1))
+
+		keys = keys[:0]
+		lp.RevRange(-3, -1, func(data []byte, index int) bool {
+			keys = append(keys, string(data))
+			return false
+		})
+		equal(t, len(keys), 3)
+		equal(t, keys[0], genKey(3))
+		equal(t, keys[2], genKey(1))
+	})
+
+	t.Run("int-encoding", func(t *testing.T) {
+		keys := []string{"0", "-1", "1234567890", "9223372036854775807", "-9223372036854775808",
+			"007", "-0", "+1", "9223372036854775808", "1e3", "hello"}
+		lp := NewListPack()
+		lp.Insert(-1, keys...)
+
+		var i int
+		lp.Range(0, -1, func(data []byte, _ int) bool {
+			equal(t, string(data), keys[i])
+			i++
+			return false
+		})
+		equal(t, i, len(keys))
+		lp.RevRange(0, -1, func(data []byte, index int) bool {
+			equal(t, string(data), keys[len(keys)-index-1])
+			return false
+		})
+
+		for _, k := range append(keys, strings.Repeat("a", 200), "") {
+			equal(t, entrySize(k), len(appendEntry(nil, k)))
+			lessOrEqual(t, entrySize(k), maxEntrySize(len(k)))
+		}
+
+		// integers are smaller
+		lp2 := NewListPack()
+		lp2.Insert(-1, "1234567890")
+		equal(t, len(lp2.data), 7)
+
+		// set between integer and string
+		ok := lp.Set(0, "abc")
+		equal(t, true, ok)
+		ok = lp.Set(10, "42")
+		equal(t, true, ok)
+		ok = lp.Set(1, "-2")
+		equal(t, true, ok)
+		val, _ := lp.Remove(0)
+		equal(t, val, "abc")
+		val, _ = lp.Remove(0)
+		equal(t, val, "-2")
+		val, _ = lp.Remove(-1)
+		equal(t, val, "42")
+
+		// remove first integer
+		index, ok := lp.RemoveFirst("1234567890")
+		equal(t, index, 0)
+		equal(t, true, ok)
+
+		// encoding is preserved by marshal
+		lp3, err := NewFromBytes(lp.ToBytes())
+		isNil(t, err)
+		equalBytes(t, lp.data, lp3.data)
+		val, _ = lp3.Remove(0)
+		equal(t, val, "9223372036854775807")
+	})
+
 	t.Run("to-bytes", func(t *testing.T) {
 		lp := genListPack(0, N)
 		data := lp.ToBytes()
@@ -260,6 +393,15 @@ func TestListPack(t *testing.T) {
 			equal(t, true, ok)
 		}
 
+		// legacy format is migrated
+		lpold, err := NewFromBytes([]byte("\x02\x00\x00\x00\x09\x00\x00\x00\x02-3\x03\x03123\x04"))
+		isNil(t, err)
+		equal(t, lpold.Size(), 2)
+		val, _ := lpold.Remove(0)
+		equal(t, val, "-3")
+		val, _ = lpold.Remove(0)
+		equal(t, val, "123")
+
 		// error
 		lpnew2, err := NewFromBytes([]byte("Hello"))
 		if lpnew2 != nil {
@@ -0,0 +1,155 @@
+package quicklist
+
+import (
+	"errors"
+)
+
+// LZF is a very small and fast compression algorithm which is used by Redis
+// to compress quicklist nodes, the format is compatible with liblzf.
+/*
+	literal run:
+	+-----------+--------------------+
+	| 000LLLLL  | L+1 literal bytes  |
+	+-----------+--------------------+
+
+	back reference (L < 7):
+	+-----------+-----------+
+	| LLLooooo  | oooooooo  |
+	+-----------+-----------+
+
+	back reference (L = 7):
+	+-----------+-----------+-----------+
+	| 111ooooo  | LLLLLLLL  | oooooooo  |
+	+-----------+-----------+-----------+
+
+	The back reference copies L+2 bytes from (current position - offset - 1).
+*/
+const (
+	lzfHashLog = 13
+	lzfMaxLit  = 1 << 5
+	lzfMaxOff  = 1 << 13
+	lzfMaxRef  = (1 << 8) + (1 << 3)
+)
+
+var ErrDecompress = errors.New("decompress error: invalid lzf data")
+
+func lzfHash(b []byte) uint32 {
+	v := uint32(b[0])<<16 | uint32(b[1])<<8 | uint32(b[2])
+	return (v * 2654435761) >> (32 - lzfHashLog)
+}
+
+// lzfCompress appends the compressed src to dst.
+func lzfCompress(dst, src []byte) []byte {
+	var htab [1 << lzfHashLog]int32
+
+	// reserve the control byte of first literal run.
+	dst = append(dst, 0)
+	litPos, lit := len(dst)-1, 0
+
+	ip := 0
+	for ip+2 < len(src) {
+		h := lzfHash(src[ip:])
+		ref := int(htab[h]) - 1
+		htab[h] = int32(ip + 1)
+
+		off := ip - ref - 1
+		if ref < 0 || off >= lzfMaxOff ||
+			src[ref] != src[ip] || src[ref+1] != src[ip+1] || src[ref+2] != src[ip+2] {
+			// literal
+			dst = append(dst, src[ip])
+			ip++
+			lit++
+			if lit == lzfMaxLit {
+				dst[litPos] = byte(lit - 1)
+				dst = append(dst, 0)
+				litPos, lit = len(dst)-1, 0
+			}
+			continue
+		}
+
+		// back reference
+		maxLen := min(len(src)-ip, lzfMaxRef)
+		n := 3
+		for n < maxLen && src[ref+n] == src[ip+n] {
+			n++
+		}
+
+		// close the literal run.
+		if lit == 0 {
+			dst = dst[:len(dst)-1]
+		} else {
+			dst[litPos] = byte(lit - 1)
+		}
+
+		n -= 2
+		if n < 7 {
+			dst = append(dst, byte(off>>8+n<<5))
+		} else {
+			dst = append(dst, byte(off>>8+7<<5), byte(n-7))
+		}
+		dst = append(dst, byte(off))
+		ip += n + 2
+
+		dst = append(dst, 0)
+		litPos, lit = len(dst)-1, 0
+	}
+
+	for ; ip < len(src); ip++ {
+		dst = append(dst, src[ip])
+		lit++
+		if lit == lzfMaxLit {
+			dst[litPos] = byte(lit - 1)
+			dst = append(dst, 0)
+			litPos, lit = len(dst)-1, 0
+		}
+	}
+
+	if lit == 0 {
+		return dst[:len(dst)-1]
+	}
+	dst[litPos] = byte(lit - 1)
+	return dst
+}
+
+// lzfDecompress appends the decompressed src to dst.
+func lzfDecompress(dst, src []byte) ([]byte, error) {
+	base := len(dst)
+	for ip := 0; ip < len(src); {
+		ctrl := int(src[ip])
+		ip++
+
+		// literal run
+		if ctrl < lzfMaxLit {
+			n := ctrl + 1
+			if ip+n > len(src) {
+				return nil, ErrDecompress
+			}
+			dst = append(dst, src[ip:ip+n]...)
+			ip += n
+			continue
+		}
+
+		// back reference
+		n := ctrl >> 5
+		if n == 7 {
+			if ip >= len(src) {
+				return nil, ErrDecompress
+			}
+			n += int(src[ip])
+			ip++
+		}
+		if ip >= len(src) {
+			return nil, ErrDecompress
+		}
+		ref := len(dst) - (ctrl&0x1f)<<8 - int(src[ip]) - 1
+		ip++
+		if ref < base {
+			return nil, ErrDecompress
+		}
+		// copy byte by byte since the reference may overlap the output.
+		for i := 0; i < n+2; i++ {
+			dst = append(dst, dst[ref+i])
+		}
+	}
+	return dst, nil
+}
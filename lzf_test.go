@@ -0,0 +1,55 @@
+package quicklist
+
+import (
+	"bytes"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestLzf(t *testing.T) {
+	t.Run("compress", func(t *testing.T) {
+		inputs := [][]byte{
+			nil,
+			[]byte("a"),
+			[]byte("abc"),
+			bytes.Repeat([]byte("a"), 1000),
+			bytes.Repeat([]byte("hello world "), 1000),
+			genListPack(0, 1000).data,
+		}
+		// random data
+		for i := 0; i < 100; i++ {
+			buf := make([]byte, rand.IntN(10000))
+			for j := range buf {
+				buf[j] = byte(rand.IntN(4) + 'a')
+			}
+			inputs = append(inputs, buf)
+		}
+
+		for _, src := range inputs {
+			dst := lzfCompress(nil, src)
+			res, err := lzfDecompress(nil, dst)
+			isNil(t, err)
+			equalBytes(t, src, res)
+		}
+
+		// compress ratio
+		src := genListPack(0, 1000).data
+		lessOrEqual(t, len(lzfCompress(nil, src)), len(src)/2)
+	})
+
+	t.Run("decompress-error", func(t *testing.T) {
+		// truncated literal run
+		_, err := lzfDecompress(nil, []byte{0x1f, 'a'})
+		isNotNil(t, err)
+
+		// reference out of bound
+		_, err = lzfDecompress(nil, []byte{0x00, 'a', 0x20, 0x01})
+		isNotNil(t, err)
+
+		// truncated reference
+		_, err = lzfDecompress(nil, []byte{0x00, 'a', 0xe0})
+		isNotNil(t, err)
+		_, err = lzfDecompress(nil, []byte{0x00, 'a', 0x20})
+		isNotNil(t, err)
+	})
+}
@@ -0,0 +1,88 @@
+package quicklist
+
+// Options is the configuration of a quicklist instance.
+type Options struct {
+	// MaxListPackSize is the max bytes of each listpack node.
+	MaxListPackSize int
+
+	// MaxListPackEntries is the max number of entries of each listpack node,
+	// 0 means no limit.
+	MaxListPackEntries int
+
+	// Fill is the same as `list-max-listpack-size` in Redis, when it is not 0,
+	// it overrides MaxListPackSize and MaxListPackEntries.
+	// Positive values cap the number of entries per listpack node,
+	// negative values select the max bytes per listpack node:
+	//
+	//	-1: 4 KB
+	//	-2: 8 KB
+	//	-3: 16 KB
+	//	-4: 32 KB
+	//	-5: 64 KB
+	Fill int
+
+	// CompressDepth is the same as `list-compress-depth` in Redis,
+	// it is the number of nodes at each end of the list that are never compressed,
+	// nodes in the middle keep their listpack data LZF-compressed.
+	// 0 means compression is disabled.
+	CompressDepth int
+
+	// CapEntries and CapBytes turn the list into a capped list like a ring buffer,
+	// pushing to one end evicts entries from the opposite end when the number of
+	// entries or the bytes of listpack data exceed the caps. The last pushed entry
+	// is always kept even if it is larger than CapBytes. 0 means no limit.
+	CapEntries int
+	CapBytes   int
+
+	// OnEvict is called with each value evicted by the caps if it is not nil,
+	// it must not modify the list.
+	OnEvict func(value string)
+}
+
+// sizeSafetyLimit is the max bytes of a listpack node when Fill is positive.
+const sizeSafetyLimit = 8192
+
+// DefaultOptions is the options used by New.
+var DefaultOptions = Options{
+	MaxListPackSize: 1024 * 1024,
+}
+
+// SetMaxListPackSize sets the max bytes of listpack nodes for lists
+// created by New afterwards, lists that are already live are not affected.
+//
+// Deprecated: use NewWithOptions instead.
+func SetMaxListPackSize(s int) {
+	DefaultOptions.MaxListPackSize = s
+}
+
+// normalize fills the invalid fields with default values.
+func (o Options) normalize() Options {
+	switch {
+	case o.Fill > 0:
+		o.MaxListPackEntries = o.Fill
+		o.MaxListPackSize = sizeSafetyLimit
+	case o.Fill < 0:
+		o.Fill = max(o.Fill, -5)
+		o.MaxListPackEntries = 0
+		o.MaxListPackSize = 4096 << (-o.Fill - 1)
+	}
+	if o.MaxListPackSize <= 0 {
+		o.MaxListPackSize = 1024 * 1024
+	}
+	if o.MaxListPackEntries < 0 {
+		o.MaxListPackEntries = 0
+	}
+	if o.CompressDepth < 0 {
+		o.CompressDepth = 0
+	}
+	o.CapEntries = max(o.CapEntries, 0)
+	o.CapBytes = max(o.CapBytes, 0)
+	return o
+}
+
+// uncapped returns the options without caps, it is used by the internal lists
+// of queues whose entries must not be evicted.
+func (o Options) uncapped() Options {
+	o.CapEntries, o.CapBytes, o.OnEvict = 0, 0, nil
+	return o
+}
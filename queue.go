@@ -0,0 +1,165 @@
+package quicklist
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// Overflow is the policy of BoundedQueue when it is full.
+type Overflow int
+
+const (
+	// OverflowBlock makes Push wait until there is room or the context is done.
+	OverflowBlock Overflow = iota
+
+	// OverflowFailFast makes Push return ErrQueueFull immediately.
+	OverflowFailFast
+
+	// OverflowEvict makes Push drop entries from the head, the opposite end of Push.
+	OverflowEvict
+)
+
+var (
+	ErrQueueFull = errors.New("queue is full")
+	ErrTooLarge  = errors.New("entry is larger than the queue budget")
+)
+
+// QueueOptions is the configuration of a bounded queue.
+type QueueOptions struct {
+	// Options of the underlying quicklist, the caps are ignored since
+	// the queue limits are enforced by MaxLen, MaxBytes and Overflow.
+	Options
+
+	// MaxLen is the max number of entries, 0 means no limit.
+	MaxLen int
+
+	// MaxBytes is the max bytes of entries measured by their encoded size
+	// in listpack data, 0 means no limit.
+	MaxBytes int
+
+	// Overflow is the policy when the queue is full.
+	Overflow Overflow
+}
+
+// BoundedQueue is a goroutine-safe FIFO queue over quicklist with limits on
+// the number of entries and bytes. Entries are pushed to the tail and popped
+// from the head.
+type BoundedQueue struct {
+	mu   sync.Mutex
+	ls   *QuickList
+	opts QueueOptions
+
+	// notFull and notEmpty are closed to wake up the blocked goroutines.
+	notFull  chan struct{}
+	notEmpty chan struct{}
+}
+
+// NewBoundedQueue create a bounded queue instance with given options.
+func NewBoundedQueue(opts QueueOptions) *BoundedQueue {
+	return &BoundedQueue{ls: NewWithOptions(opts.uncapped()), opts: opts}
+}
+
+// fits reports whether an entry of size bytes can be pushed without overflow.
+func (q *BoundedQueue) fits(size int) bool {
+	if q.opts.MaxLen > 0 && q.ls.Size()+1 > q.opts.MaxLen {
+		return false
+	}
+	return q.opts.MaxBytes <= 0 || q.ls.Bytes()+size <= q.opts.MaxBytes
+}
+
+// waitFor releases the held mu until ch is closed or ctx is done.
+func waitFor(ctx context.Context, mu *sync.Mutex, ch *chan struct{}) error {
+	if *ch == nil {
+		*ch = make(chan struct{})
+	}
+	c := *ch
+	mu.Unlock()
+	defer mu.Lock()
+	select {
+	case <-c:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// wake closes ch to wake up all goroutines blocked on it.
+func wake(ch *chan struct{}) {
+	if *ch != nil {
+		close(*ch)
+		*ch = nil
+	}
+}
+
+// Push adds value to the tail of queue, see Overflow for the behavior when it is full.
+// It returns ErrTooLarge if value exceeds MaxBytes by itself.
+func (q *BoundedQueue) Push(ctx context.Context, value string) error {
+	size := entrySize(value)
+	if q.opts.MaxBytes > 0 && size > q.opts.MaxBytes {
+		return ErrTooLarge
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for !q.fits(size) {
+		switch q.opts.Overflow {
+		case OverflowFailFast:
+			return ErrQueueFull
+		case OverflowEvict:
+			q.pop()
+		default:
+			if err := waitFor(ctx, &q.mu, &q.notFull); err != nil {
+				return err
+			}
+		}
+	}
+	q.ls.RPush(value)
+	wake(&q.notEmpty)
+	return nil
+}
+
+func (q *BoundedQueue) pop() (string, bool) {
+	val, ok := q.ls.LPop()
+	if ok {
+		wake(&q.notFull)
+	}
+	return val, ok
+}
+
+// TryPop removes the head entry of queue without blocking.
+func (q *BoundedQueue) TryPop() (string, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.pop()
+}
+
+// Pop removes the head entry of queue, it blocks until the queue is not empty or ctx is done.
+func (q *BoundedQueue) Pop(ctx context.Context) (string, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for {
+		if val, ok := q.pop(); ok {
+			return val, nil
+		}
+		if err := waitFor(ctx, &q.mu, &q.notEmpty); err != nil {
+			return "", err
+		}
+	}
+}
+
+// Len returns the number of entries in queue.
+func (q *BoundedQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.ls.Size()
+}
+
+// Bytes returns the encoded size of entries in queue.
+func (q *BoundedQueue) Bytes() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.ls.Bytes()
+}
@@ -0,0 +1,147 @@
+package quicklist
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBoundedQueue(t *testing.T) {
+	const N = 1000
+	ctx := context.Background()
+
+	t.Run("failFast", func(t *testing.T) {
+		q := NewBoundedQueue(QueueOptions{MaxLen: 10, Overflow: OverflowFailFast})
+		for i := 0; i < 10; i++ {
+			isNil(t, q.Push(ctx, genKey(i)))
+		}
+		equal(t, q.Push(ctx, "x"), ErrQueueFull)
+		equal(t, q.Len(), 10)
+
+		v, ok := q.TryPop()
+		equal(t, ok, true)
+		equal(t, v, genKey(0))
+		isNil(t, q.Push(ctx, "x"))
+
+		// the caps of list do not bypass the overflow policy.
+		opts := QueueOptions{MaxLen: 10, Overflow: OverflowFailFast}
+		opts.CapEntries = 2
+		q = NewBoundedQueue(opts)
+		for i := 0; i < 10; i++ {
+			isNil(t, q.Push(ctx, genKey(i)))
+		}
+		equal(t, q.Push(ctx, "x"), ErrQueueFull)
+		equal(t, q.Len(), 10)
+	})
+
+	t.Run("bytes", func(t *testing.T) {
+		q := NewBoundedQueue(QueueOptions{MaxBytes: 100, Overflow: OverflowFailFast})
+		var total int
+		for i := 0; ; i++ {
+			k := genKey(i)
+			if total+entrySize(k) > 100 {
+				equal(t, q.Push(ctx, k), ErrQueueFull)
+				break
+			}
+			isNil(t, q.Push(ctx, k))
+			total += entrySize(k)
+		}
+		equal(t, q.Bytes(), total)
+
+		// bytes are measured from listpack data
+		var size int
+		for n := q.ls.head; n != nil; n = n.next {
+			size += len(n.data)
+		}
+		equal(t, size, total)
+
+		equal(t, q.Push(ctx, string(make([]byte, 101))), ErrTooLarge)
+		for {
+			if _, ok := q.TryPop(); !ok {
+				break
+			}
+		}
+		equal(t, q.Bytes(), 0)
+	})
+
+	t.Run("evict", func(t *testing.T) {
+		q := NewBoundedQueue(QueueOptions{MaxLen: 10, MaxBytes: 200, Overflow: OverflowEvict})
+		for i := 0; i < N; i++ {
+			isNil(t, q.Push(ctx, strconv.Itoa(i)))
+		}
+		equal(t, q.Len(), 10)
+		for i := N - 10; i < N; i++ {
+			v, _ := q.TryPop()
+			equal(t, v, strconv.Itoa(i))
+		}
+
+		// a large entry evicts more than one entry
+		for i := 0; i < 10; i++ {
+			isNil(t, q.Push(ctx, genKey(i)))
+		}
+		isNil(t, q.Push(ctx, string(make([]byte, 150))))
+		lessOrEqual(t, q.Bytes(), 200)
+		lessOrEqual(t, q.Len(), 6)
+	})
+
+	t.Run("block", func(t *testing.T) {
+		q := NewBoundedQueue(QueueOptions{MaxLen: 1})
+		isNil(t, q.Push(ctx, "1"))
+
+		// timeout
+		tctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		equal(t, errors.Is(q.Push(tctx, "2"), context.DeadlineExceeded), true)
+
+		done := make(chan struct{})
+		go func() {
+			isNil(t, q.Push(ctx, "2"))
+			close(done)
+		}()
+		v, err := q.Pop(ctx)
+		isNil(t, err)
+		equal(t, v, "1")
+		<-done
+		v, _ = q.Pop(ctx)
+		equal(t, v, "2")
+
+		// pop blocks on empty queue
+		tctx, cancel = context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		_, err = q.Pop(tctx)
+		equal(t, errors.Is(err, context.DeadlineExceeded), true)
+	})
+
+	t.Run("stress", func(t *testing.T) {
+		q := NewBoundedQueue(QueueOptions{MaxLen: 16, MaxBytes: 256})
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		seen := make(map[string]bool)
+		for g := 0; g < 4; g++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < N; i++ {
+					isNil(t, q.Push(ctx, genKey(g*N+i)))
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				for i := 0; i < N; i++ {
+					v, err := q.Pop(ctx)
+					isNil(t, err)
+					mu.Lock()
+					seen[v] = true
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+		equal(t, len(seen), 4*N)
+		equal(t, q.Len(), 0)
+		equal(t, q.Bytes(), 0)
+	})
+}
@@ -0,0 +1,81 @@
+package quicklist
+
+import (
+	"iter"
+)
+
+// All returns an iterator over index and entry of list from head to tail.
+// The entry is only valid during the iteration, copy it if needed.
+func (ls *QuickList) All() iter.Seq2[int, []byte] {
+	return ls.Slice(0, -1)
+}
+
+// Values returns an iterator over entries of list from head to tail.
+func (ls *QuickList) Values() iter.Seq[string] {
+	return func(yield func(string) bool) {
+		ls.iterFront(0, -1, func(data []byte) bool {
+			return !yield(string(data))
+		})
+	}
+}
+
+// Backward returns an iterator over index and entry of list from tail to head.
+func (ls *QuickList) Backward() iter.Seq2[int, []byte] {
+	return func(yield func(int, []byte) bool) {
+		i := ls.Size()
+		ls.iterBack(0, -1, func(data []byte) bool {
+			i--
+			return !yield(i, data)
+		})
+	}
+}
+
+// Slice returns an iterator over index and entry of list in [start, end),
+// negative start and end count from the tail the same as Range.
+func (ls *QuickList) Slice(start, end int) iter.Seq2[int, []byte] {
+	return func(yield func(int, []byte) bool) {
+		start, end := normalizeRange(start, end, ls.Size())
+		i := start
+		ls.iterFront(start, end, func(data []byte) bool {
+			stop := !yield(i, data)
+			i++
+			return stop
+		})
+	}
+}
+
+// All returns an iterator over index and entry of listpack from head to tail.
+// The entry is only valid during the iteration, copy it if needed.
+func (lp *ListPack) All() iter.Seq2[int, []byte] {
+	return lp.Slice(0, -1)
+}
+
+// Values returns an iterator over entries of listpack from head to tail.
+func (lp *ListPack) Values() iter.Seq[string] {
+	return func(yield func(string) bool) {
+		lp.iterFront(0, -1, func(data []byte, _ int, _, _ int) bool {
+			return !yield(string(data))
+		})
+	}
+}
+
+// Backward returns an iterator over index and entry of listpack from tail to head.
+func (lp *ListPack) Backward() iter.Seq2[int, []byte] {
+	return func(yield func(int, []byte) bool) {
+		size := lp.Size()
+		lp.iterBack(0, -1, func(data []byte, i int, _, _ int) bool {
+			return !yield(size-i-1, data)
+		})
+	}
+}
+
+// Slice returns an iterator over index and entry of listpack in [start, end),
+// negative start and end count from the tail the same as Range.
+func (lp *ListPack) Slice(start, end int) iter.Seq2[int, []byte] {
+	return func(yield func(int, []byte) bool) {
+		start, end := normalizeRange(start, end, lp.Size())
+		lp.iterFront(start, end, func(data []byte, i int, _, _ int) bool {
+			return !yield(i, data)
+		})
+	}
+}
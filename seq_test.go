@@ -0,0 +1,111 @@
+package quicklist
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSeq(t *testing.T) {
+	const N = 1000
+
+	t.Run("list", func(t *testing.T) {
+		ls := NewWithOptions(Options{MaxListPackEntries: 8, CompressDepth: 1})
+		for i := 0; i < N; i++ {
+			ls.RPush(genKey(i))
+		}
+
+		var count int
+		for i, data := range ls.All() {
+			equal(t, i, count)
+			equal(t, string(data), genKey(i))
+			count++
+		}
+		equal(t, count, N)
+
+		values := slices.Collect(ls.Values())
+		equal(t, len(values), N)
+		for i, v := range values {
+			equal(t, v, genKey(i))
+		}
+
+		count = N
+		for i, data := range ls.Backward() {
+			count--
+			equal(t, i, count)
+			equal(t, string(data), genKey(i))
+		}
+		equal(t, count, 0)
+
+		count = 0
+		for i, data := range ls.Slice(-10, -1) {
+			equal(t, string(data), genKey(N-10+count))
+			equal(t, i, N-10+count)
+			count++
+		}
+		equal(t, count, 10)
+
+		// break
+		count = 0
+		for i := range ls.All() {
+			if i == 100 {
+				break
+			}
+			count++
+		}
+		equal(t, count, 100)
+		for range ls.Backward() {
+			break
+		}
+		for range ls.Values() {
+			break
+		}
+
+		// empty
+		for range New().All() {
+			t.Fatal("empty list")
+		}
+		for range ls.Slice(N, -1) {
+			t.Fatal("empty slice")
+		}
+	})
+
+	t.Run("listpack", func(t *testing.T) {
+		lp := genListPack(0, N)
+
+		var count int
+		for i, data := range lp.All() {
+			equal(t, i, count)
+			equal(t, string(data), genKey(i))
+			count++
+		}
+		equal(t, count, N)
+
+		values := slices.Collect(lp.Values())
+		equal(t, len(values), N)
+
+		count = N
+		for i, data := range lp.Backward() {
+			count--
+			equal(t, i, count)
+			equal(t, string(data), genKey(i))
+		}
+		equal(t, count, 0)
+
+		count = 0
+		for i, data := range lp.Slice(5, 15) {
+			equal(t, i, 5+count)
+			equal(t, string(data), genKey(i))
+			count++
+		}
+		equal(t, count, 10)
+
+		for i := range lp.All() {
+			if i == 10 {
+				break
+			}
+		}
+		for range lp.Backward() {
+			break
+		}
+	})
+}
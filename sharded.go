@@ -0,0 +1,139 @@
+package quicklist
+
+import (
+	"encoding/binary"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// ShardedOptions is the configuration of a sharded queue.
+type ShardedOptions struct {
+	// Options of the underlying quicklists.
+	Options
+
+	// Shards is the number of shards, default is GOMAXPROCS.
+	Shards int
+
+	// Ordered enables the global FIFO order. Each entry is tagged with a sequence
+	// number, and Pop takes the smallest one among the heads of all shards.
+	// Pushing is still sharded but popping locks all shards.
+	Ordered bool
+}
+
+// shard is a quicklist with its own lock, padded to avoid false sharing.
+type shard struct {
+	mu sync.Mutex
+	ls *QuickList
+	_  [48]byte
+}
+
+// ShardedQueue is a goroutine-safe multi-producer multi-consumer FIFO queue
+// built from several quicklist shards with their own locks. Push spreads
+// entries over shards in round-robin, and Pop steals from other shards when
+// its own shard is contended or empty, so the order is FIFO per shard only
+// unless Ordered is set.
+type ShardedQueue struct {
+	shards  []shard
+	ordered bool
+	push    atomic.Uint64
+	pop     atomic.Uint64
+	seq     atomic.Uint64
+	size    atomic.Int64
+}
+
+// NewShardedQueue create a sharded queue instance with given options.
+func NewShardedQueue(opts ShardedOptions) *ShardedQueue {
+	if opts.Shards <= 0 {
+		opts.Shards = runtime.GOMAXPROCS(0)
+	}
+	q := &ShardedQueue{shards: make([]shard, opts.Shards), ordered: opts.Ordered}
+	for i := range q.shards {
+		q.shards[i].ls = NewWithOptions(opts.uncapped())
+	}
+	return q
+}
+
+// Push adds value to the tail of queue.
+func (q *ShardedQueue) Push(value string) {
+	s := &q.shards[q.push.Add(1)%uint64(len(q.shards))]
+	s.mu.Lock()
+	if q.ordered {
+		// the sequence is taken under the lock, so each shard is sorted by it.
+		buf := binary.BigEndian.AppendUint64(bpool.Get(8 + len(value))[:0], q.seq.Add(1))
+		buf = append(buf, value...)
+		s.ls.RPushBytes(buf)
+		bpool.Put(buf)
+	} else {
+		s.ls.RPush(value)
+	}
+	q.size.Add(1)
+	s.mu.Unlock()
+}
+
+// TryPop removes an entry from the head of queue without blocking.
+func (q *ShardedQueue) TryPop() (string, bool) {
+	if q.size.Load() <= 0 {
+		return "", false
+	}
+	if q.ordered {
+		return q.popOrdered()
+	}
+
+	n := uint64(len(q.shards))
+	start := q.pop.Add(1)
+	// skip the contended shards first, then wait for them.
+	for pass := 0; pass < 2; pass++ {
+		for i := uint64(0); i < n; i++ {
+			s := &q.shards[(start+i)%n]
+			if pass == 0 {
+				if !s.mu.TryLock() {
+					continue
+				}
+			} else {
+				s.mu.Lock()
+			}
+			val, ok := s.ls.LPop()
+			s.mu.Unlock()
+			if ok {
+				q.size.Add(-1)
+				return val, true
+			}
+		}
+	}
+	return "", false
+}
+
+// popOrdered pops the entry with the smallest sequence among the heads of shards.
+func (q *ShardedQueue) popOrdered() (string, bool) {
+	for i := range q.shards {
+		q.shards[i].mu.Lock()
+	}
+	defer func() {
+		for i := range q.shards {
+			q.shards[i].mu.Unlock()
+		}
+	}()
+
+	best := -1
+	var bestSeq uint64
+	for i := range q.shards {
+		q.shards[i].ls.iterFront(0, 1, func(data []byte) bool {
+			if seq := binary.BigEndian.Uint64(data); best < 0 || seq < bestSeq {
+				best, bestSeq = i, seq
+			}
+			return true
+		})
+	}
+	if best < 0 {
+		return "", false
+	}
+	record, _ := q.shards[best].ls.LPop()
+	q.size.Add(-1)
+	return record[8:], true
+}
+
+// Len returns the number of entries in queue.
+func (q *ShardedQueue) Len() int {
+	return int(q.size.Load())
+}
@@ -0,0 +1,106 @@
+package quicklist
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestShardedQueue(t *testing.T) {
+	const N = 1000
+	const G = 8
+
+	t.Run("steal", func(t *testing.T) {
+		q := NewShardedQueue(ShardedOptions{Shards: 4})
+		for i := 0; i < N; i++ {
+			q.Push(genKey(i))
+			v, ok := q.TryPop()
+			equal(t, ok, true)
+			equal(t, v, genKey(i))
+		}
+		_, ok := q.TryPop()
+		equal(t, ok, false)
+		equal(t, q.Len(), 0)
+	})
+
+	t.Run("ordered", func(t *testing.T) {
+		q := NewShardedQueue(ShardedOptions{Shards: 4, Ordered: true})
+		for i := 0; i < N; i++ {
+			q.Push(genKey(i))
+		}
+		equal(t, q.Len(), N)
+		for i := 0; i < N; i++ {
+			v, ok := q.TryPop()
+			equal(t, ok, true)
+			equal(t, v, genKey(i))
+		}
+		_, ok := q.TryPop()
+		equal(t, ok, false)
+	})
+
+	for _, ordered := range []bool{false, true} {
+		name := "concurrent"
+		if ordered {
+			name += "/ordered"
+		}
+		t.Run(name, func(t *testing.T) {
+			q := NewShardedQueue(ShardedOptions{Shards: 4, Ordered: ordered})
+			var wg sync.WaitGroup
+			var mu sync.Mutex
+			seen := make(map[string]bool)
+
+			for g := 0; g < G; g++ {
+				wg.Add(2)
+				go func() {
+					defer wg.Done()
+					for i := 0; i < N; i++ {
+						q.Push(genKey(g*N + i))
+					}
+				}()
+				go func() {
+					defer wg.Done()
+					for i := 0; i < N; {
+						v, ok := q.TryPop()
+						if !ok {
+							continue
+						}
+						mu.Lock()
+						equal(t, seen[v], false)
+						seen[v] = true
+						mu.Unlock()
+						i++
+					}
+				}()
+			}
+			wg.Wait()
+			equal(t, len(seen), G*N)
+			equal(t, q.Len(), 0)
+		})
+	}
+
+	t.Run("ordered/producers", func(t *testing.T) {
+		// entries of each producer are popped in the order they are pushed.
+		q := NewShardedQueue(ShardedOptions{Shards: 4, Ordered: true})
+		var wg sync.WaitGroup
+		for g := 0; g < G; g++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < N; i++ {
+					q.Push(genKey(g*N + i))
+				}
+			}()
+		}
+		wg.Wait()
+
+		last := make(map[int]string)
+		for i := 0; i < G*N; i++ {
+			v, ok := q.TryPop()
+			equal(t, ok, true)
+			n, _ := strconv.ParseInt(v, 16, 64)
+			k := int(n) / N
+			equal(t, v > last[k], true)
+			last[k] = v
+		}
+	})
+}
@@ -0,0 +1,285 @@
+package quicklist
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"iter"
+	"strconv"
+	"unsafe"
+)
+
+// Codec encodes values of type T into entries of quicklist and decodes them back.
+type Codec[T any] interface {
+	// Append appends the encoded v to dst and returns the extended buffer.
+	Append(dst []byte, v T) ([]byte, error)
+
+	// Decode decodes the value from data, data must not be retained after return.
+	Decode(data []byte) (T, error)
+}
+
+type integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+type float interface {
+	~float32 | ~float64
+}
+
+// IntCodec encodes integers as decimal strings,
+// so they are stored with the integer encoding of listpack.
+type IntCodec[T integer] struct{}
+
+func (IntCodec[T]) Append(dst []byte, v T) ([]byte, error) {
+	if ^T(0) < 0 {
+		return strconv.AppendInt(dst, int64(v), 10), nil
+	}
+	return strconv.AppendUint(dst, uint64(v), 10), nil
+}
+
+func (IntCodec[T]) Decode(data []byte) (T, error) {
+	var v T
+	bitSize := int(unsafe.Sizeof(v)) * 8
+	if ^T(0) < 0 {
+		n, err := strconv.ParseInt(b2s(data), 10, bitSize)
+		return T(n), err
+	}
+	n, err := strconv.ParseUint(b2s(data), 10, bitSize)
+	return T(n), err
+}
+
+// FloatCodec encodes floats as the shortest decimal strings that round trip.
+type FloatCodec[T float] struct{}
+
+func (FloatCodec[T]) Append(dst []byte, v T) ([]byte, error) {
+	return strconv.AppendFloat(dst, float64(v), 'g', -1, int(unsafe.Sizeof(v))*8), nil
+}
+
+func (FloatCodec[T]) Decode(data []byte) (T, error) {
+	var v T
+	f, err := strconv.ParseFloat(b2s(data), int(unsafe.Sizeof(v))*8)
+	return T(f), err
+}
+
+// BytesCodec stores []byte as it is, decoded values are copied.
+type BytesCodec struct{}
+
+func (BytesCodec) Append(dst []byte, v []byte) ([]byte, error) {
+	return append(dst, v...), nil
+}
+
+func (BytesCodec) Decode(data []byte) ([]byte, error) {
+	return append([]byte{}, data...), nil
+}
+
+// StringCodec stores string as it is.
+type StringCodec struct{}
+
+func (StringCodec) Append(dst []byte, v string) ([]byte, error) {
+	return append(dst, v...), nil
+}
+
+func (StringCodec) Decode(data []byte) (string, error) {
+	return string(data), nil
+}
+
+// BinaryCodec encodes types that implement encoding.BinaryMarshaler and
+// encoding.BinaryUnmarshaler on the pointer receiver, e.g. BinaryCodec[time.Time, *time.Time].
+type BinaryCodec[T any, PT interface {
+	*T
+	encoding.BinaryMarshaler
+	encoding.BinaryUnmarshaler
+}] struct{}
+
+func (BinaryCodec[T, PT]) Append(dst []byte, v T) ([]byte, error) {
+	data, err := PT(&v).MarshalBinary()
+	if err != nil {
+		return dst, err
+	}
+	return append(dst, data...), nil
+}
+
+func (BinaryCodec[T, PT]) Decode(data []byte) (T, error) {
+	var v T
+	err := PT(&v).UnmarshalBinary(data)
+	return v, err
+}
+
+// QuickListOf is a typed quicklist, values are encoded by Codec into entries.
+// Entries that fail to decode, e.g. pushed by List with another format,
+// are reported as errors wrapping ErrDecode.
+type QuickListOf[T any] struct {
+	ls    *QuickList
+	codec Codec[T]
+	buf   []byte
+	err   error
+}
+
+var ErrDecode = errors.New("quicklist: decode error")
+
+// NewOf create a typed quicklist instance with DefaultOptions.
+func NewOf[T any](codec Codec[T]) *QuickListOf[T] {
+	return NewOfWithOptions(codec, DefaultOptions)
+}
+
+// NewOfWithOptions create a typed quicklist instance with given options.
+func NewOfWithOptions[T any](codec Codec[T], opts Options) *QuickListOf[T] {
+	return &QuickListOf[T]{ls: NewWithOptions(opts), codec: codec}
+}
+
+// List returns the underlying quicklist.
+func (l *QuickListOf[T]) List() *QuickList {
+	return l.ls
+}
+
+func (l *QuickListOf[T]) encode(v T) ([]byte, error) {
+	var err error
+	l.buf, err = l.codec.Append(l.buf[:0], v)
+	return l.buf, err
+}
+
+func (l *QuickListOf[T]) decode(data []byte) (T, error) {
+	v, err := l.codec.Decode(data)
+	if err != nil {
+		return v, fmt.Errorf("%w: %q: %w", ErrDecode, data, err)
+	}
+	return v, nil
+}
+
+// LPush pushes values to the head, it stops at the first value that fails
+// to encode and returns the error, values before it are pushed.
+func (l *QuickListOf[T]) LPush(values ...T) error {
+	for _, v := range values {
+		data, err := l.encode(v)
+		if err != nil {
+			return err
+		}
+		l.ls.LPushBytes(data)
+	}
+	return nil
+}
+
+// RPush pushes values to the tail, it stops at the first value that fails
+// to encode and returns the error, values before it are pushed.
+func (l *QuickListOf[T]) RPush(values ...T) error {
+	for _, v := range values {
+		data, err := l.encode(v)
+		if err != nil {
+			return err
+		}
+		l.ls.RPushBytes(data)
+	}
+	return nil
+}
+
+// Index returns the value at index i, negative index counts from the tail.
+func (l *QuickListOf[T]) Index(i int) (v T, ok bool, err error) {
+	l.buf, ok = l.ls.IndexBytes(i, l.buf[:0])
+	if ok {
+		v, err = l.decode(l.buf)
+	}
+	return
+}
+
+// Set updates the value at index, negative index counts from the tail.
+func (l *QuickListOf[T]) Set(index int, v T) (bool, error) {
+	data, err := l.encode(v)
+	if err != nil {
+		return false, err
+	}
+	return l.ls.Set(index, b2s(data)), nil
+}
+
+// LPop removes the head value, the entry is removed even if it fails to decode.
+func (l *QuickListOf[T]) LPop() (v T, ok bool, err error) {
+	l.buf, ok = l.ls.LPopAppend(l.buf[:0])
+	if ok {
+		v, err = l.decode(l.buf)
+	}
+	return
+}
+
+// RPop removes the tail value, the entry is removed even if it fails to decode.
+func (l *QuickListOf[T]) RPop() (v T, ok bool, err error) {
+	l.buf, ok = l.ls.RPopAppend(l.buf[:0])
+	if ok {
+		v, err = l.decode(l.buf)
+	}
+	return
+}
+
+// Remove deletes the value at index, negative index counts from the tail.
+// The entry is removed even if it fails to decode.
+func (l *QuickListOf[T]) Remove(index int) (v T, ok bool, err error) {
+	l.buf, ok = l.ls.removeAppend(l.buf[:0], index)
+	if ok {
+		v, err = l.decode(l.buf)
+	}
+	return
+}
+
+// Size
+func (l *QuickListOf[T]) Size() int {
+	return l.ls.Size()
+}
+
+// Range calls f for values in [start, end), see QuickList.Range.
+// It stops at the first entry that fails to decode and returns the error.
+func (l *QuickListOf[T]) Range(start, end int, f func(v T) (stop bool)) (err error) {
+	l.ls.Range(start, end, func(data []byte) bool {
+		var v T
+		if v, err = l.decode(data); err != nil {
+			return true
+		}
+		return f(v)
+	})
+	return
+}
+
+// RevRange is the same as Range but iterates from the tail, see QuickList.RevRange.
+func (l *QuickListOf[T]) RevRange(start, end int, f func(v T) (stop bool)) (err error) {
+	l.ls.RevRange(start, end, func(data []byte) bool {
+		var v T
+		if v, err = l.decode(data); err != nil {
+			return true
+		}
+		return f(v)
+	})
+	return
+}
+
+// All returns an iterator over index and value of list from head to tail.
+// It stops at the first entry that fails to decode, the error is reported by Err.
+func (l *QuickListOf[T]) All() iter.Seq2[int, T] {
+	return func(yield func(int, T) bool) {
+		l.err = nil
+		for i, data := range l.ls.All() {
+			v, err := l.decode(data)
+			if err != nil {
+				l.err = err
+				return
+			}
+			if !yield(i, v) {
+				return
+			}
+		}
+	}
+}
+
+// Values returns an iterator over values of list from head to tail.
+// It stops at the first entry that fails to decode, the error is reported by Err.
+func (l *QuickListOf[T]) Values() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for _, v := range l.All() {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// Err returns the decode error that stopped the last iteration of All or Values.
+func (l *QuickListOf[T]) Err() error {
+	return l.err
+}
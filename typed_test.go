@@ -0,0 +1,173 @@
+package quicklist
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"slices"
+	"testing"
+	"time"
+)
+
+// badBinary fails to marshal.
+type badBinary struct{}
+
+func (*badBinary) MarshalBinary() ([]byte, error) { return nil, errors.New("bad binary") }
+func (*badBinary) UnmarshalBinary([]byte) error   { return nil }
+
+func TestTyped(t *testing.T) {
+	const N = 1000
+
+	t.Run("int", func(t *testing.T) {
+		ls := NewOfWithOptions[int64](IntCodec[int64]{}, Options{MaxListPackEntries: 16, CompressDepth: 1})
+		for i := 0; i < N; i++ {
+			isNil(t, ls.RPush(int64(i)*1e9))
+		}
+		isNil(t, ls.LPush(math.MinInt64, math.MaxInt64))
+		equal(t, ls.Size(), N+2)
+
+		v, ok, err := ls.Index(0)
+		isNil(t, err)
+		equal(t, ok, true)
+		equal(t, v, int64(math.MaxInt64))
+		v, _, _ = ls.Index(-1)
+		equal(t, v, int64(N-1)*1e9)
+		_, ok, err = ls.Index(N + 2)
+		isNil(t, err)
+		equal(t, ok, false)
+
+		// stored with integer encoding
+		equal(t, ls.List().head.data[0]&1, byte(encInt))
+
+		v, ok, _ = ls.LPop()
+		equal(t, ok, true)
+		equal(t, v, int64(math.MaxInt64))
+		v, _, _ = ls.LPop()
+		equal(t, v, int64(math.MinInt64))
+
+		ok, err = ls.Set(1, -1)
+		isNil(t, err)
+		equal(t, ok, true)
+		v, ok, _ = ls.Remove(1)
+		equal(t, ok, true)
+		equal(t, v, int64(-1))
+
+		// 1e9 is removed
+		want := []int64{0}
+		for i := 2; i < N; i++ {
+			want = append(want, int64(i)*1e9)
+		}
+		var got []int64
+		err = ls.Range(0, -1, func(v int64) bool {
+			got = append(got, v)
+			return false
+		})
+		isNil(t, err)
+		equal(t, slices.Equal(got, want), true)
+
+		v, ok, _ = ls.RPop()
+		equal(t, ok, true)
+		equal(t, v, int64(N-1)*1e9)
+
+		// unsigned
+		u := NewOf[uint64](IntCodec[uint64]{})
+		u.RPush(0, math.MaxUint64)
+		equal(t, slices.Equal(slices.Collect(u.Values()), []uint64{0, math.MaxUint64}), true)
+
+		// out of range of int8
+		_, err = IntCodec[int8]{}.Decode([]byte("128"))
+		isNotNil(t, err)
+		_, err = IntCodec[uint8]{}.Decode([]byte("-1"))
+		isNotNil(t, err)
+	})
+
+	t.Run("float", func(t *testing.T) {
+		ls := NewOf[float64](FloatCodec[float64]{})
+		values := []float64{0, -1.5, math.Pi, math.MaxFloat64, math.SmallestNonzeroFloat64, math.Inf(1)}
+		ls.RPush(values...)
+		equal(t, slices.Equal(slices.Collect(ls.Values()), values), true)
+
+		f := NewOf[float32](FloatCodec[float32]{})
+		f.RPush(0.1, math.MaxFloat32)
+		v, _, _ := f.Index(0)
+		equal(t, v, float32(0.1))
+		v, _, _ = f.RPop()
+		equal(t, v, float32(math.MaxFloat32))
+	})
+
+	t.Run("bytes", func(t *testing.T) {
+		ls := NewOf[[]byte](BytesCodec{})
+		ls.RPush([]byte("hello"), []byte{}, []byte("world"))
+		for i, v := range ls.All() {
+			equal(t, bytes.Equal(v, [][]byte{[]byte("hello"), {}, []byte("world")}[i]), true)
+		}
+		// decoded values are copied
+		a, _, _ := ls.Index(0)
+		b, _, _ := ls.Index(2)
+		equal(t, string(a), "hello")
+		equal(t, string(b), "world")
+
+		s := NewOf[string](StringCodec{})
+		s.RPush("a", "b")
+		v, _, _ := s.RPop()
+		equal(t, v, "b")
+	})
+
+	t.Run("binary", func(t *testing.T) {
+		ls := NewOf[time.Time](BinaryCodec[time.Time, *time.Time]{})
+		now := time.Now()
+		for i := 0; i < N; i++ {
+			ls.RPush(now.Add(time.Duration(i) * time.Second))
+		}
+		var count int
+		err := ls.RevRange(0, -1, func(v time.Time) bool {
+			equal(t, v.Equal(now.Add(time.Duration(N-count-1)*time.Second)), true)
+			count++
+			return false
+		})
+		isNil(t, err)
+		equal(t, count, N)
+
+		v, ok, _ := ls.LPop()
+		equal(t, ok, true)
+		equal(t, v.Equal(now), true)
+
+		// marshal error
+		bad := NewOf[badBinary](BinaryCodec[badBinary, *badBinary]{})
+		isNotNil(t, bad.RPush(badBinary{}))
+		_, err = bad.Set(0, badBinary{})
+		isNotNil(t, err)
+		equal(t, bad.Size(), 0)
+	})
+
+	t.Run("corrupted", func(t *testing.T) {
+		ls := NewOf[int](IntCodec[int]{})
+		ls.RPush(1)
+		ls.List().RPush("abc")
+		ls.RPush(2)
+
+		_, ok, err := ls.Index(1)
+		equal(t, ok, true)
+		equal(t, errors.Is(err, ErrDecode), true)
+
+		var got []int
+		err = ls.Range(0, -1, func(v int) bool {
+			got = append(got, v)
+			return false
+		})
+		equal(t, errors.Is(err, ErrDecode), true)
+		equal(t, slices.Equal(got, []int{1}), true)
+
+		got = slices.Collect(ls.Values())
+		equal(t, errors.Is(ls.Err(), ErrDecode), true)
+		equal(t, slices.Equal(got, []int{1}), true)
+
+		// the entry is removed even if it fails to decode.
+		_, ok, err = ls.Remove(1)
+		equal(t, ok, true)
+		isNotNil(t, err)
+		got = slices.Collect(ls.Values())
+		isNil(t, ls.Err())
+		equal(t, slices.Equal(got, []int{1, 2}), true)
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/binary"
 	"math/bits"
 	"slices"
+	"strconv"
 	"unsafe"
 )
 
@@ -47,6 +48,49 @@ func SizeUvarint(x uint64) int {
 	return int(9*uint32(bits.Len64(x))+64) / 64
 }
 
+// sizeVarint returns the size of binary.AppendVarint(nil, x).
+func sizeVarint(x int64) int {
+	ux := uint64(x) << 1
+	if x < 0 {
+		ux = ^ux
+	}
+	return SizeUvarint(ux)
+}
+
+// parseInt parses s as an integer only if it round-trips exactly,
+// which means strconv.FormatInt(n, 10) == s.
+func parseInt(s string) (int64, bool) {
+	if len(s) == 0 || len(s) > 20 {
+		return 0, false
+	}
+	digits := s
+	if s[0] == '-' {
+		digits = s[1:]
+	}
+	if len(digits) == 0 || digits[0] == '0' && len(s) > 1 {
+		return 0, false
+	}
+	for i := 0; i < len(digits); i++ {
+		if digits[i] < '0' || digits[i] > '9' {
+			return 0, false
+		}
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	return n, err == nil
+}
+
+// normalizeRange resolves negative start and end of range [start, end)
+// like Redis, the start -n means size-n, the end -n means size-n+1.
+func normalizeRange(start, end, size int) (int, int) {
+	if start < 0 {
+		start += size
+	}
+	if end < 0 {
+		end += size + 1
+	}
+	return max(start, 0), max(min(end, size), 0)
+}
+
 func s2b(str *string) []byte {
 	strHeader := (*[2]uintptr)(unsafe.Pointer(str))
 	byteSliceHeader := [3]uintptr{
@@ -5,6 +5,7 @@ import (
 	"encoding/binary"
 	"math"
 	"slices"
+	"strconv"
 	"testing"
 )
 
@@ -34,6 +35,24 @@ func TestUtils(t *testing.T) {
 			equal(t, s1, s3)
 		}
 	})
+
+	t.Run("sizeVarint", func(t *testing.T) {
+		for _, n := range []int64{0, 1, -1, 63, -64, 64, -65, math.MaxInt64, math.MinInt64} {
+			equal(t, len(binary.AppendVarint(nil, n)), sizeVarint(n))
+		}
+	})
+
+	t.Run("parseInt", func(t *testing.T) {
+		for _, s := range []string{"0", "1", "-1", "1234567890", "9223372036854775807", "-9223372036854775808"} {
+			n, ok := parseInt(s)
+			equal(t, true, ok)
+			equal(t, strconv.FormatInt(n, 10), s)
+		}
+		for _, s := range []string{"", "-", "-0", "01", "+1", " 1", "1a", "1.0", "9223372036854775808", "123456789012345678901"} {
+			_, ok := parseInt(s)
+			equal(t, false, ok)
+		}
+	})
 }
 
 // for test
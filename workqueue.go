@@ -0,0 +1,235 @@
+package quicklist
+
+import (
+	"context"
+	"encoding/binary"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultVisibility is the default visibility timeout of WorkQueue.
+const DefaultVisibility = 30 * time.Second
+
+// WorkQueueOptions is the configuration of a work queue.
+type WorkQueueOptions struct {
+	// Options of the underlying quicklists.
+	Options
+
+	// Visibility is how long a reserved item stays invisible before it is
+	// requeued to the head if not acknowledged, default is DefaultVisibility.
+	Visibility time.Duration
+
+	// Now returns the current time, default is time.Now.
+	Now func() time.Time
+}
+
+// WorkQueue is a goroutine-safe reliable queue like the RPOPLPUSH pattern of Redis.
+// Reserved items are moved from the ready list to the in-flight list until they are
+// acknowledged by Ack, or requeued by Nack or the visibility timeout.
+/*
+	in-flight record:
+	+--------+--------------+---------+
+	| id(8B) | deadline(8B) | payload |
+	+--------+--------------+---------+
+
+	Each reservation gets a new increasing id, so records are sorted by id
+	and deadline, Ack and Nack locate the record by binary search.
+*/
+type WorkQueue struct {
+	mu       sync.Mutex
+	ready    *QuickList
+	inflight *QuickList
+	opts     WorkQueueOptions
+	nextID   uint64
+	buf      []byte
+
+	// notEmpty is closed to wake up the blocked Reserve.
+	notEmpty chan struct{}
+}
+
+const recordHeader = 16
+
+// NewWorkQueue create a work queue instance with given options.
+func NewWorkQueue(opts WorkQueueOptions) *WorkQueue {
+	if opts.Visibility <= 0 {
+		opts.Visibility = DefaultVisibility
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &WorkQueue{
+		ready:    NewWithOptions(opts.Options),
+		inflight: NewWithOptions(opts.uncapped()),
+		opts:     opts,
+	}
+}
+
+// Push adds payload to the tail of ready list.
+func (q *WorkQueue) Push(payloads ...string) {
+	q.mu.Lock()
+	q.ready.RPush(payloads...)
+	wake(&q.notEmpty)
+	q.mu.Unlock()
+}
+
+// TryReserve is the same as Reserve but returns false immediately if no item is ready.
+func (q *WorkQueue) TryReserve() (uint64, string, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.requeueExpired()
+	return q.reserve()
+}
+
+// Reserve moves the head item of ready list to the in-flight list and returns its id,
+// it blocks until an item is ready or ctx is done.
+func (q *WorkQueue) Reserve(ctx context.Context) (uint64, string, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for {
+		q.requeueExpired()
+		if id, payload, ok := q.reserve(); ok {
+			return id, payload, nil
+		}
+		if err := q.wait(ctx); err != nil {
+			return 0, "", err
+		}
+	}
+}
+
+// wait releases the lock until an item is pushed, the earliest in-flight item
+// expires or ctx is done.
+func (q *WorkQueue) wait(ctx context.Context) error {
+	if q.notEmpty == nil {
+		q.notEmpty = make(chan struct{})
+	}
+	ch := q.notEmpty
+
+	var expire <-chan time.Time
+	var ok bool
+	if q.buf, ok = q.inflight.IndexBytes(0, q.buf[:0]); ok {
+		timer := time.NewTimer(q.deadline(q.buf).Sub(q.opts.Now()))
+		defer timer.Stop()
+		expire = timer.C
+	}
+
+	q.mu.Unlock()
+	defer q.mu.Lock()
+	select {
+	case <-ch:
+	case <-expire:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
+
+func (q *WorkQueue) reserve() (uint64, string, bool) {
+	payload, ok := q.ready.LPop()
+	if !ok {
+		return 0, "", false
+	}
+	q.nextID++
+	id := q.nextID
+	deadline := q.opts.Now().Add(q.opts.Visibility)
+
+	q.buf = binary.BigEndian.AppendUint64(q.buf[:0], id)
+	q.buf = binary.BigEndian.AppendUint64(q.buf, uint64(deadline.UnixNano()))
+	q.buf = append(q.buf, payload...)
+	q.inflight.RPushBytes(q.buf)
+	return id, payload, true
+}
+
+func (q *WorkQueue) deadline(record []byte) time.Time {
+	return time.Unix(0, int64(binary.BigEndian.Uint64(record[8:])))
+}
+
+// find returns the index of in-flight record with id, or -1 if not found.
+func (q *WorkQueue) find(id uint64) int {
+	size := q.inflight.Size()
+	i := sort.Search(size, func(i int) bool {
+		q.buf, _ = q.inflight.IndexBytes(i, q.buf[:0])
+		return binary.BigEndian.Uint64(q.buf) >= id
+	})
+	if i == size {
+		return -1
+	}
+	q.buf, _ = q.inflight.IndexBytes(i, q.buf[:0])
+	if binary.BigEndian.Uint64(q.buf) != id {
+		return -1
+	}
+	return i
+}
+
+// Ack acknowledges the reserved item and deletes it,
+// returns false if it is not in-flight, e.g. already requeued by timeout.
+func (q *WorkQueue) Ack(id uint64) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	i := q.find(id)
+	if i < 0 {
+		return false
+	}
+	q.inflight.Remove(i)
+	return true
+}
+
+// Nack requeues the reserved item to the head of ready list immediately,
+// returns false if it is not in-flight.
+func (q *WorkQueue) Nack(id uint64) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	i := q.find(id)
+	if i < 0 {
+		return false
+	}
+	record, _ := q.inflight.Remove(i)
+	q.ready.LPush(record[recordHeader:])
+	wake(&q.notEmpty)
+	return true
+}
+
+// RequeueExpired requeues the in-flight items whose visibility timeout expired
+// to the head of ready list, it is also done by Reserve. Returns the number of them.
+func (q *WorkQueue) RequeueExpired() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.requeueExpired()
+}
+
+func (q *WorkQueue) requeueExpired() int {
+	now := q.opts.Now()
+	var k int
+	q.inflight.Range(0, -1, func(record []byte) bool {
+		if q.deadline(record).After(now) {
+			return true
+		}
+		k++
+		return false
+	})
+	if k == 0 {
+		return 0
+	}
+	// push back in reverse order so the oldest item is at the head.
+	records := q.inflight.LPopN(k)
+	for i := len(records) - 1; i >= 0; i-- {
+		q.ready.LPush(records[i][recordHeader:])
+	}
+	wake(&q.notEmpty)
+	return k
+}
+
+// Len returns the number of ready items.
+func (q *WorkQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.ready.Size()
+}
+
+// InFlight returns the number of reserved items that are not acknowledged.
+func (q *WorkQueue) InFlight() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.inflight.Size()
+}
@@ -0,0 +1,177 @@
+package quicklist
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a manual clock for testing timeouts.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Add(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+func TestWorkQueue(t *testing.T) {
+	const N = 1000
+	ctx := context.Background()
+
+	t.Run("ack", func(t *testing.T) {
+		q := NewWorkQueue(WorkQueueOptions{Options: Options{MaxListPackEntries: 8}})
+		for i := 0; i < N; i++ {
+			q.Push(genKey(i))
+		}
+		ids := make([]uint64, N)
+		for i := 0; i < N; i++ {
+			id, payload, err := q.Reserve(ctx)
+			isNil(t, err)
+			equal(t, payload, genKey(i))
+			ids[i] = id
+		}
+		equal(t, q.Len(), 0)
+		equal(t, q.InFlight(), N)
+
+		// ack in random order
+		for _, i := range rand.Perm(N) {
+			equal(t, q.Ack(ids[i]), true)
+			equal(t, q.Ack(ids[i]), false)
+		}
+		equal(t, q.InFlight(), 0)
+		equal(t, q.Ack(N+1), false)
+
+		_, _, ok := q.TryReserve()
+		equal(t, ok, false)
+	})
+
+	t.Run("nack", func(t *testing.T) {
+		q := NewWorkQueue(WorkQueueOptions{})
+		q.Push("a", "b", "c")
+		id1, _, _ := q.TryReserve()
+		id2, _, _ := q.TryReserve()
+
+		equal(t, q.Nack(id2), true)
+		equal(t, q.Nack(id2), false)
+		equal(t, q.Nack(id1), true)
+		equal(t, q.InFlight(), 0)
+
+		// requeued to the head, and reserved with new ids.
+		for _, want := range []string{"a", "b", "c"} {
+			id, payload, ok := q.TryReserve()
+			equal(t, ok, true)
+			equal(t, payload, want)
+			equal(t, id > id2, true)
+		}
+	})
+
+	t.Run("visibility", func(t *testing.T) {
+		clock := &fakeClock{now: time.Unix(1000, 0)}
+		q := NewWorkQueue(WorkQueueOptions{Visibility: time.Minute, Now: clock.Now})
+		q.Push("a", "b", "c", "d")
+
+		id1, _, _ := q.TryReserve()
+		clock.Add(30 * time.Second)
+		q.TryReserve()
+		id3, _, _ := q.TryReserve()
+		equal(t, q.RequeueExpired(), 0)
+
+		// a expires
+		clock.Add(30 * time.Second)
+		equal(t, q.RequeueExpired(), 1)
+		equal(t, q.Ack(id1), false)
+		equal(t, q.InFlight(), 2)
+
+		// b and c expire, the order is kept at the head.
+		clock.Add(time.Minute)
+		id, payload, ok := q.TryReserve()
+		equal(t, ok, true)
+		equal(t, payload, "b")
+		equal(t, q.Ack(id3), false)
+		equal(t, q.Ack(id), true)
+
+		var payloads []string
+		for {
+			_, payload, ok := q.TryReserve()
+			if !ok {
+				break
+			}
+			payloads = append(payloads, payload)
+		}
+		equal(t, len(payloads), 3)
+		equal(t, payloads[0], "c")
+		equal(t, payloads[1], "a")
+		equal(t, payloads[2], "d")
+	})
+
+	t.Run("block", func(t *testing.T) {
+		q := NewWorkQueue(WorkQueueOptions{Visibility: 20 * time.Millisecond})
+
+		tctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		_, _, err := q.Reserve(tctx)
+		equal(t, errors.Is(err, context.DeadlineExceeded), true)
+
+		go q.Push("a")
+		_, payload, err := q.Reserve(ctx)
+		isNil(t, err)
+		equal(t, payload, "a")
+
+		// wakes up when the in-flight item expires
+		_, payload, err = q.Reserve(ctx)
+		isNil(t, err)
+		equal(t, payload, "a")
+	})
+
+	t.Run("stress", func(t *testing.T) {
+		q := NewWorkQueue(WorkQueueOptions{})
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		seen := make(map[string]bool)
+		for g := 0; g < 4; g++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < N; i++ {
+					q.Push(genKey(g*N + i))
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				for i := 0; i < N; {
+					id, payload, err := q.Reserve(ctx)
+					isNil(t, err)
+					// nack some items and reserve them again
+					if i%3 == 0 && q.Nack(id) {
+						i++
+						continue
+					}
+					equal(t, q.Ack(id), true)
+					mu.Lock()
+					equal(t, seen[payload], false)
+					seen[payload] = true
+					mu.Unlock()
+					i++
+				}
+			}()
+		}
+		wg.Wait()
+		mu.Lock()
+		defer mu.Unlock()
+		equal(t, len(seen)+q.Len(), 4*N)
+		equal(t, q.InFlight(), 0)
+	})
+}